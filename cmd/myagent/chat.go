@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"hivemind-go/pkg/agent"
+	"hivemind-go/pkg/llmclient"
+)
+
+const chatHelp = `可用命令:
+  /reset          清空对话并取消所有后台任务
+  /history        查看对话历史
+  /tools          查看可用工具
+  /jobs           查看后台任务
+  /cancel <id>    取消后台任务 (可使用 ID 前缀)
+  /save [path]    将对话保存为 JSON 文件
+  /model [name]   查看或切换模型配置
+  /help           显示帮助
+  /exit           退出
+运行过程中按 Ctrl-C 取消当前运行，后台任务不受影响；运行期间仍可使用 /jobs、/cancel 等命令。`
+
+type chatSession struct {
+	agent   *agent.Agent
+	out     io.Writer
+	printer *eventPrinter
+
+	noCache bool
+
+	mu        sync.Mutex
+	cancelRun context.CancelFunc
+}
+
+func chat(args []string) error {
+	fs := flag.NewFlagSet("chat", flag.ContinueOnError)
+	global := addGlobalFlags(fs)
+	agentName := fs.String("agent", "", "要对话的 agent 名称 (默认使用 default_agent)")
+	noCache := fs.Bool("no-cache", false, "跳过 LLM 响应缓存 (既不读取也不写入)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	env, err := global.load()
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	chatAgent, err := env.buildQuietAgent(*agentName)
+	if err != nil {
+		return err
+	}
+	defer chatAgent.Close()
+
+	out := &syncWriter{w: os.Stdout}
+	s := &chatSession{agent: chatAgent, out: out, printer: &eventPrinter{out: out}, noCache: *noCache}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+	go s.handleInterrupts(sigCh)
+
+	fmt.Fprintf(s.out, "正在与 %s 对话 (模型: %s)。输入 /help 查看命令。\n", chatAgent.Name(), chatAgent.Model())
+
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		readErr <- scanner.Err()
+		close(lines)
+	}()
+
+	var running chan struct{}
+	fmt.Fprint(s.out, "\n> ")
+	for {
+		select {
+		case <-running:
+			running = nil
+			fmt.Fprint(s.out, "\n> ")
+			continue
+		case line, ok := <-lines:
+			if !ok {
+				s.stop(running)
+				fmt.Fprintln(s.out)
+				return <-readErr
+			}
+
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "":
+			case strings.HasPrefix(line, "/"):
+				if exit := s.command(line, running != nil); exit {
+					s.stop(running)
+					return nil
+				}
+			case running != nil:
+				fmt.Fprintln(s.out, "当前运行尚未结束，可以使用 /jobs、/cancel 等命令，或按 Ctrl-C 取消运行。")
+			default:
+				running = s.start(line)
+				continue
+			}
+			if running == nil {
+				fmt.Fprint(s.out, "\n> ")
+			}
+		}
+	}
+}
+
+func (s *chatSession) handleInterrupts(sigCh <-chan os.Signal) {
+	for range sigCh {
+		s.mu.Lock()
+		cancel := s.cancelRun
+		s.mu.Unlock()
+
+		if cancel != nil {
+			fmt.Fprintln(s.out, "\n正在取消当前运行...")
+			cancel()
+		} else {
+			fmt.Fprint(s.out, "\n(输入 /exit 或按 Ctrl-D 退出)\n> ")
+		}
+	}
+}
+
+func (s *chatSession) start(input string) chan struct{} {
+	ctx, cancel := context.WithCancel(agent.ContextWithDetachedJobs(context.Background()))
+	if s.noCache {
+		ctx = llmclient.WithCacheBypass(ctx)
+	}
+
+	s.mu.Lock()
+	s.cancelRun = cancel
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			s.mu.Lock()
+			s.cancelRun = nil
+			s.mu.Unlock()
+			cancel()
+		}()
+		s.run(ctx, input)
+	}()
+	return done
+}
+
+func (s *chatSession) stop(running chan struct{}) {
+	if running == nil {
+		return
+	}
+	s.mu.Lock()
+	cancel := s.cancelRun
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	<-running
+}
+
+func (s *chatSession) run(ctx context.Context, input string) {
+	result, err := s.agent.Run(agent.ContextWithEventHandler(ctx, s.printer.print), input)
+	switch {
+	case errors.Is(err, context.Canceled):
+		fmt.Fprintln(s.out, "运行已取消。")
+	case err != nil:
+		fmt.Fprintf(s.out, "运行出错: %v\n", err)
+	default:
+		fmt.Fprintf(s.out, "\n%s\n", result)
+	}
+}
+
+func (s *chatSession) command(line string, running bool) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	if running && (name == "/reset" || name == "/model" && arg != "") {
+		fmt.Fprintf(s.out, "运行中不能使用 %s，请等待运行结束或按 Ctrl-C 取消。\n", name)
+		return false
+	}
+
+	switch name {
+	case "/exit", "/quit":
+		return true
+	case "/help":
+		fmt.Fprintln(s.out, chatHelp)
+	case "/reset":
+		s.agent.Reset()
+		fmt.Fprintln(s.out, "对话已清空。")
+	case "/history":
+		s.printHistory()
+	case "/tools":
+		for _, tool := range s.agent.Tools() {
+			fmt.Fprintf(s.out, "- %s: %s\n", tool.Name(), truncate(tool.Description(), 120))
+		}
+	case "/jobs":
+		s.printJobs()
+	case "/cancel":
+		s.cancelJob(arg)
+	case "/save":
+		s.save(arg)
+	case "/model":
+		if arg == "" {
+			fmt.Fprintf(s.out, "当前模型: %s\n", s.agent.Model())
+			return false
+		}
+		if err := s.agent.SetModel(arg); err != nil {
+			fmt.Fprintln(s.out, err)
+			return false
+		}
+		fmt.Fprintf(s.out, "已切换到模型: %s\n", arg)
+	default:
+		fmt.Fprintf(s.out, "未知命令 %s，输入 /help 查看命令。\n", name)
+	}
+	return false
+}
+
+func (s *chatSession) printHistory() {
+	count := 0
+	for _, m := range s.agent.Messages() {
+		if m.Type == "system_prompt" {
+			continue
+		}
+		count++
+		fmt.Fprintf(s.out, "%3d %-9s %-22s %s\n", count, m.Role, m.Type, truncate(m.Content, 160))
+	}
+	if count == 0 {
+		fmt.Fprintln(s.out, "对话为空。")
+	}
+}
+
+func (s *chatSession) printJobs() {
+	jobs := s.agent.Jobs()
+	if len(jobs) == 0 {
+		fmt.Fprintln(s.out, "没有后台任务。")
+		return
+	}
+	for _, job := range jobs {
+		status := "运行中"
+		if job.Done {
+			status = "已完成"
+		}
+		fmt.Fprintf(s.out, "%s  %-16s %s  %s\n", job.ID, job.ToolName, status, time.Since(job.StartedAt).Round(time.Second))
+	}
+}
+
+func (s *chatSession) cancelJob(prefix string) {
+	if prefix == "" {
+		fmt.Fprintln(s.out, "用法: /cancel <job id>")
+		return
+	}
+
+	var matches []string
+	for _, job := range s.agent.Jobs() {
+		if strings.HasPrefix(job.ID, prefix) {
+			matches = append(matches, job.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		fmt.Fprintf(s.out, "后台任务 '%s' 不存在。\n", prefix)
+	case 1:
+		if err := s.agent.CancelJob(matches[0]); err != nil {
+			fmt.Fprintln(s.out, err)
+			return
+		}
+		fmt.Fprintf(s.out, "已取消后台任务 %s。\n", matches[0])
+	default:
+		fmt.Fprintf(s.out, "前缀 '%s' 匹配多个后台任务: %s\n", prefix, strings.Join(matches, ", "))
+	}
+}
+
+func (s *chatSession) save(path string) {
+	if path == "" {
+		path = fmt.Sprintf("%s_chat_%s.json", s.agent.Name(), time.Now().Format("20060102_150405"))
+	}
+
+	data, err := json.MarshalIndent(s.agent.Messages(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(s.out, "保存失败: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Fprintf(s.out, "保存失败: %v\n", err)
+		return
+	}
+	fmt.Fprintf(s.out, "对话已保存到 %s\n", path)
+}
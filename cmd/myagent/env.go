@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"hivemind-go/pkg/agent"
+	"hivemind-go/pkg/builder"
+	"hivemind-go/pkg/llmclient"
+	"hivemind-go/pkg/logging"
+	"hivemind-go/pkg/tools"
+	"hivemind-go/pkg/tracing"
+)
+
+const (
+	envConfigPath = "HIVEMIND_CONFIG"
+	envAgentsPath = "HIVEMIND_AGENTS"
+)
+
+type globalOptions struct {
+	configPath string
+	agentsPath string
+}
+
+func addGlobalFlags(fs *flag.FlagSet) *globalOptions {
+	o := &globalOptions{}
+	fs.StringVar(&o.configPath, "config", envOr(envConfigPath, "config.toml"), "LLM 配置文件路径 (环境变量 "+envConfigPath+")")
+	fs.StringVar(&o.agentsPath, "agents", envOr(envAgentsPath, "agents.toml"), "agent 定义文件路径 (环境变量 "+envAgentsPath+")")
+	return o
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+type environment struct {
+	config      *llmclient.AppConfig
+	llmClient   *llmclient.LLMClient
+	definitions *builder.Definitions
+	tracer      *tracing.Tracer
+}
+
+func (o *globalOptions) load() (*environment, error) {
+	config, err := llmclient.LoadConfig(o.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("无法加载配置 %s: %w", o.configPath, err)
+	}
+
+	definitions, err := builder.LoadDefinitions(o.agentsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tracer, err := definitions.Tracer()
+	if err != nil {
+		return nil, fmt.Errorf("无法初始化 tracing: %w", err)
+	}
+	if tracer != nil {
+		tracing.SetDefault(tracer)
+	}
+
+	return &environment{
+		config:      config,
+		llmClient:   llmclient.NewLLMClient(config),
+		definitions: definitions,
+		tracer:      tracer,
+	}, nil
+}
+
+func (e *environment) buildQuietAgent(name string) (*agent.Agent, error) {
+	config, err := e.definitions.AgentConfig(name, builder.DefaultRegistry)
+	if err != nil {
+		return nil, err
+	}
+	if config.Logging == nil {
+		config.Logging = logging.DefaultConfig()
+	}
+	config.Logging.Stdout = false
+
+	return builder.BuildAgent(config, e.llmClient, tools.NewContext())
+}
+
+func (e *environment) Close() {
+	if e.tracer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e.tracer.Shutdown(ctx)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"hivemind-go/pkg/builder"
+	"hivemind-go/pkg/tools"
+)
+
+func runExamples(args []string) error {
+	fs := flag.NewFlagSet("examples", flag.ContinueOnError)
+	global := addGlobalFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	env, err := global.load()
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	managerAgent, err := builder.BuildFromDefinitions(env.definitions, "ManagerAgent", env.llmClient, tools.NewContext())
+	if err != nil {
+		return fmt.Errorf("无法构建主管 agent: %w", err)
+	}
+	defer managerAgent.Close()
+
+	fmt.Println("\n============================")
+	fmt.Println("=== 示例 1: 串行任务委托 ===")
+	fmt.Println("============================")
+
+	userInputSerial := "请帮我将 '你好世界' 这段文字写入 'greeting.txt' 文件。"
+
+	result, err := managerAgent.Run(ctx, userInputSerial)
+	if err != nil {
+		fmt.Printf("Agent 执行出错: %v\n", err)
+	} else {
+		fmt.Printf("\n最终结果: %s\n", result)
+	}
+
+	fmt.Println("\n\n===============================")
+	fmt.Println("=== 示例 2: 并行任务委托 ===")
+	fmt.Println("===============================")
+
+	managerAgent2, err := builder.BuildFromDefinitions(env.definitions, "ManagerAgent", env.llmClient, tools.NewContext())
+	if err != nil {
+		return fmt.Errorf("无法构建主管 agent: %w", err)
+	}
+	defer managerAgent2.Close()
+
+	userInputParallel := "请并行执行以下任务：1. 将 '第一个文件' 写入 'file1.txt'。 2. 将 '第二个文件' 写入 'file2.txt'。"
+	result, err = managerAgent2.Run(ctx, userInputParallel)
+	if err != nil {
+		fmt.Printf("Agent 执行出错: %v\n", err)
+	} else {
+		fmt.Printf("\n最终结果: %s\n", result)
+	}
+
+	fmt.Println("\n\n====================================")
+	fmt.Println("=== 示例 3: 异步后台任务委托 ===")
+	fmt.Println("====================================")
+	managerAgent3, err := builder.BuildFromDefinitions(env.definitions, "ManagerAgent", env.llmClient, tools.NewContext())
+	if err != nil {
+		return fmt.Errorf("无法构建主管 agent: %w", err)
+	}
+	defer managerAgent3.Close()
+	userInputAsync := "请在后台执行以下任务: 1. 将 '后台文件一' 写入 'bg_file1.txt'。 2. 将 '后台文件二' 写入 'bg_file2.txt'。在这两个任务运行时，请立刻读取 'greeting.txt' 文件的内容。最后，等待所有后台任务完成后，告诉我所有任务都已成功。"
+	result, err = managerAgent3.Run(ctx, userInputAsync)
+	if err != nil {
+		fmt.Printf("Agent 执行出错: %v\n", err)
+	} else {
+		fmt.Printf("\n最终结果: %s\n", result)
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"hivemind-go/pkg/builder"
+	"hivemind-go/pkg/tools"
+)
+
+func init() {
+	builder.RegisterTool(builder.DefaultRegistry, "FileTool", func(env builder.BuildEnv, _ builder.NoOptions) (tools.Tool, error) {
+		return &FileTool{ctx: env.Ctx}, nil
+	})
+}
+
+type FileTool struct {
+	ctx *tools.Context
+}
+
+func (f *FileTool) Name() string { return "FileTool" }
+func (f *FileTool) Description() string {
+	return "一个可以读写文件的工具。在写入前，请务必先思考一下要写入什么内容。"
+}
+func (f *FileTool) Parameters() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"operation": {"type": "string", "enum": ["read", "write"]},
+			"path": {"type": "string"},
+			"content": {"type": "string", "description": "写入文件时需要"},
+			"run_in_background": {"type": "boolean", "description": "如果为 true, 则在后台运行工具, 不阻塞主流程。"}
+		},
+		"required": ["operation", "path"]
+	}`)
+}
+func (f *FileTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	op, _ := args["operation"].(string)
+	path, _ := args["path"].(string)
+
+	time.Sleep(1 * time.Second)
+
+	switch op {
+	case "read":
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("成功读取文件 '%s' 的内容: %s", path, string(content)), nil
+	case "write":
+		content, ok := args["content"].(string)
+		if !ok {
+			return "", fmt.Errorf("写入操作需要 'content' 参数")
+		}
+
+		err := os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("成功将内容写入文件 '%s'。", path), nil
+	}
+	return "", fmt.Errorf("不支持的操作: %s", op)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"hivemind-go/pkg/builder"
+)
+
+func listTools(args []string) error {
+	fs := flag.NewFlagSet("list-tools", flag.ContinueOnError)
+	global := addGlobalFlags(fs)
+	agentName := fs.String("agent", "", "列出该 agent 构建后的工具及说明")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *agentName == "" {
+		fmt.Println("工具:")
+		for _, name := range builder.DefaultRegistry.ToolNames() {
+			fmt.Printf("  %s\n", name)
+		}
+		fmt.Println("Assistant:")
+		for _, name := range builder.DefaultRegistry.AssistantNames() {
+			fmt.Printf("  %s\n", name)
+		}
+		return nil
+	}
+
+	env, err := global.load()
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	toolAgent, err := env.buildQuietAgent(*agentName)
+	if err != nil {
+		return err
+	}
+	defer toolAgent.Close()
+
+	for _, tool := range toolAgent.Tools() {
+		fmt.Printf("%s\n    %s\n", tool.Name(), tool.Description())
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"hivemind-go/pkg/transcript"
+)
+
+type eventPrinter struct {
+	mu  sync.Mutex
+	out io.Writer
+
+	showRuns bool
+}
+
+func (p *eventPrinter) print(event transcript.Record) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	indent := ""
+	if event.ParentRunID != "" {
+		indent = fmt.Sprintf("    [%s] ", event.Agent)
+	}
+
+	switch event.Type {
+	case "run_start":
+		if event.ParentRunID != "" || p.showRuns {
+			fmt.Fprintf(p.out, "%s开始: %s\n", indent, truncate(event.Content, 200))
+		}
+	case "run_end":
+		if event.Error != "" && (event.ParentRunID != "" || p.showRuns) {
+			fmt.Fprintf(p.out, "%s失败: %s\n", indent, truncate(event.Error, 200))
+		} else if event.ParentRunID != "" || p.showRuns {
+			fmt.Fprintf(p.out, "%s完成: %s\n", indent, truncate(event.Content, 200))
+		}
+	case "llm_output":
+		if event.Thought != "" {
+			fmt.Fprintf(p.out, "%s[思考] %s\n", indent, truncate(event.Thought, 300))
+		}
+		if event.Action != "" && event.Action != "finish" {
+			args, _ := json.Marshal(event.ToolArgs)
+			fmt.Fprintf(p.out, "%s[动作] %s %s\n", indent, event.Action, truncate(string(args), 200))
+		}
+	case "tool_result":
+		if event.JobID != "" {
+			fmt.Fprintf(p.out, "%s[后台] %s 已启动 (job %s)\n", indent, event.Tool, event.JobID)
+		} else if event.Error != "" {
+			fmt.Fprintf(p.out, "%s[失败] %s: %s\n", indent, event.Tool, truncate(event.Error, 200))
+		} else {
+			fmt.Fprintf(p.out, "%s[结果] %s: %s\n", indent, event.Tool, truncate(event.ToolResult, 200))
+		}
+	case "tool_error", "parse_error":
+		fmt.Fprintf(p.out, "%s[错误] %s\n", indent, truncate(event.Content, 200))
+	case "background_tool_result":
+		fmt.Fprintf(p.out, "%s[后台完成] %s (job %s): %s\n", indent, event.Tool, event.JobID, truncate(event.ToolResult, 200))
+	case "background_tool_error":
+		fmt.Fprintf(p.out, "%s[后台失败] %s (job %s): %s\n", indent, event.Tool, event.JobID, truncate(event.Error, 200))
+	case "context_overflow":
+		fmt.Fprintf(p.out, "%s[上下文] 超出模型窗口 (%s): %s\n", indent, event.Status, event.Content)
+	case "history_summary":
+		fmt.Fprintf(p.out, "%s[摘要] %s\n", indent, truncate(event.Content, 200))
+	}
+}
+
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+func truncate(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "..."
+}
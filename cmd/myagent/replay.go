@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"hivemind-go/pkg/transcript"
+)
+
+func replay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	runID := fs.String("run", "", "只回放指定运行 (及其子 agent) 的记录")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: myagent replay [参数] <transcript.jsonl>")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return newUsageError("需要且只能指定一个 transcript 文件")
+	}
+
+	records, err := transcript.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	included := map[string]bool{*runID: true}
+	printer := &eventPrinter{out: os.Stdout, showRuns: true}
+	for _, rec := range records {
+		if *runID != "" {
+			if !included[rec.RunID] && !included[rec.ParentRunID] {
+				continue
+			}
+			included[rec.RunID] = true
+		}
+		if rec.Type == "run_start" && rec.ParentRunID == "" {
+			fmt.Printf("\n=== %s  %s (run %s) ===\n", rec.Time.Format("2006-01-02 15:04:05"), rec.Agent, rec.RunID)
+		}
+		printer.print(rec)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"hivemind-go/pkg/agent"
+	"hivemind-go/pkg/llmclient"
+	"hivemind-go/pkg/transcript"
+)
+
+func runTask(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	global := addGlobalFlags(fs)
+	agentName := fs.String("agent", "", "执行任务的 agent 名称 (默认使用 default_agent)")
+	quiet := fs.Bool("quiet", false, "不在标准错误输出中显示运行步骤")
+	timeout := fs.Duration("timeout", 0, "运行超时时间 (0 表示不限制)")
+	noCache := fs.Bool("no-cache", false, "跳过 LLM 响应缓存 (既不读取也不写入)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: myagent run [参数] [任务]")
+		fmt.Fprintln(fs.Output(), "未提供任务或任务为 '-' 时从标准输入读取。")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	task, err := readTask(fs.Args(), os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	env, err := global.load()
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	taskAgent, err := env.buildQuietAgent(*agentName)
+	if err != nil {
+		return err
+	}
+	defer taskAgent.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	if *noCache {
+		ctx = llmclient.WithCacheBypass(ctx)
+	}
+	var printer *eventPrinter
+	if !*quiet {
+		printer = &eventPrinter{out: os.Stderr}
+	}
+	exhausted := false
+	ctx = agent.ContextWithEventHandler(ctx, func(event transcript.Record) {
+		if event.Type == "run_end" && event.ParentRunID == "" && event.Status == agent.StatusMaxIterations {
+			exhausted = true
+		}
+		if printer != nil {
+			printer.print(event)
+		}
+	})
+
+	start := time.Now()
+	result, err := taskAgent.Run(ctx, task)
+	if err != nil {
+		return err
+	}
+	if !*quiet {
+		fmt.Fprintf(os.Stderr, "完成，用时 %s\n", time.Since(start).Round(time.Millisecond))
+	}
+	fmt.Println(result)
+	if exhausted {
+		return errMaxIterations
+	}
+	return nil
+}
+
+func readTask(args []string, stdin io.Reader) (string, error) {
+	task := strings.TrimSpace(strings.Join(args, " "))
+	if task == "" || task == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("无法读取标准输入: %w", err)
+		}
+		task = strings.TrimSpace(string(data))
+	}
+	if task == "" {
+		return "", newUsageError("未提供任务")
+	}
+	return task, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"hivemind-go/pkg/metrics"
+	"hivemind-go/pkg/server"
+)
+
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	global := addGlobalFlags(fs)
+	addr := fs.String("addr", ":8080", "HTTP 监听地址")
+	maxConcurrent := fs.Int("max-concurrent", 4, "同时运行的 agent 数量上限")
+	maxQueued := fs.Int("max-queued", 100, "排队等待的运行数量上限 (0 表示不限制)")
+	runTimeout := fs.Duration("run-timeout", 10*time.Minute, "单次运行的超时时间 (0 表示不限制)")
+	watchConfig := fs.Bool("watch-config", true, "配置文件变化时自动重新加载模型配置")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	env, err := global.load()
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	registry := metrics.NewRegistry()
+	metrics.SetDefault(registry)
+
+	manager := server.NewManager(&server.DefinitionsFactory{
+		Definitions: env.definitions,
+		LLMClient:   env.llmClient,
+	}, server.ManagerConfig{
+		MaxConcurrent: *maxConcurrent,
+		MaxQueued:     *maxQueued,
+		RunTimeout:    *runTimeout,
+	})
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(manager, server.WithMetricsHandler(registry.Handler())),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *watchConfig {
+		if err := env.llmClient.WatchConfig(ctx, global.configPath); err != nil {
+			return err
+		}
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("HTTP 服务已启动", "addr", *addr, "agents", manager.Agents())
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("HTTP 服务异常退出: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	slog.Info("正在关闭 HTTP 服务...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("关闭 HTTP 服务失败: %w", err)
+	}
+	return manager.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"hivemind-go/pkg/builder"
+)
+
+func validateConfig(args []string) error {
+	fs := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	global := addGlobalFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	env, err := global.load()
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	if err := env.definitions.Validate(builder.DefaultRegistry, env.llmClient); err != nil {
+		return fmt.Errorf("agent 定义校验失败:\n%w", err)
+	}
+
+	fmt.Printf("配置有效: %d 个模型配置, %d 个 agent\n", len(env.config.Providers), len(env.definitions.Agents))
+	return nil
+}
@@ -2,9 +2,11 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"strings"
+	"io"
+	"log/slog"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,7 +14,10 @@ import (
 
 	"hivemind-go/pkg/history"
 	"hivemind-go/pkg/llmclient"
+	"hivemind-go/pkg/prompts"
 	"hivemind-go/pkg/tools"
+	"hivemind-go/pkg/tracing"
+	"hivemind-go/pkg/transcript"
 	"hivemind-go/pkg/types"
 )
 
@@ -21,11 +26,24 @@ type Agent struct {
 	llmClient       *llmclient.LLMClient
 	jsonOutputLLM   *JSONOutputLLM
 	tools           map[string]tools.Tool
+	toolOrder       []string
 	systemPrompt    string
+	fullPrompt      string
+	responseFormat  llmclient.ResponseFormat
+	prompts         *prompts.Set
 	maxIterations   int
 	historyStrategy history.Strategy
+	overflow        OverflowStrategy
 	messages        []types.Message
-	logger          *log.Logger
+	logger          *slog.Logger
+	closers         []io.Closer
+	transcript      transcript.Writer
+	eventHandler    EventHandler
+
+	runID       string
+	parentRunID string
+	iteration   int
+	runEvents   EventHandler
 
 	mu sync.Mutex
 
@@ -39,18 +57,32 @@ type Job struct {
 	ToolName  string
 	ToolInput map[string]interface{}
 
+	StartedAt time.Time
+
 	ResultChan chan string
 	ErrChan    chan error
 	Ctx        context.Context
 	CancelFunc context.CancelFunc
 }
 
+type JobInfo struct {
+	ID        string
+	ToolName  string
+	ToolInput map[string]interface{}
+	StartedAt time.Time
+	Done      bool
+}
+
 type AgentOption func(*Agent)
 
 func WithTools(agentTools ...tools.Tool) AgentOption {
 	return func(a *Agent) {
 		a.tools = make(map[string]tools.Tool)
+		a.toolOrder = nil
 		for _, t := range agentTools {
+			if _, exists := a.tools[t.Name()]; !exists {
+				a.toolOrder = append(a.toolOrder, t.Name())
+			}
 			a.tools[t.Name()] = t
 		}
 	}
@@ -62,6 +94,12 @@ func WithSystemPrompt(prompt string) AgentOption {
 	}
 }
 
+func WithPrompts(promptSet *prompts.Set) AgentOption {
+	return func(a *Agent) {
+		a.prompts = promptSet
+	}
+}
+
 func WithMaxIterations(n int) AgentOption {
 	return func(a *Agent) {
 		a.maxIterations = n
@@ -74,129 +112,422 @@ func WithHistoryStrategy(strategy history.Strategy) AgentOption {
 	}
 }
 
-func WithLogger(logger *log.Logger) AgentOption {
+func WithLogger(logger *slog.Logger) AgentOption {
 	return func(a *Agent) {
 		a.logger = logger
 	}
 }
 
+func WithTranscript(w transcript.Writer) AgentOption {
+	return func(a *Agent) {
+		a.transcript = w
+	}
+}
+
+func WithCloser(closer io.Closer) AgentOption {
+	return func(a *Agent) {
+		a.closers = append(a.closers, closer)
+	}
+}
+
 func NewAgent(name string, llmClient *llmclient.LLMClient, opts ...AgentOption) *Agent {
 
 	a := &Agent{
 		name:            name,
 		llmClient:       llmClient,
 		jsonOutputLLM:   NewJSONOutputLLM(llmClient),
-		systemPrompt:    "你是一个有用的 AI 助手。",
+		prompts:         prompts.Default(),
 		maxIterations:   25,
 		historyStrategy: &history.NoOpStrategy{},
+		overflow:        OverflowTruncate,
 		messages:        []types.Message{},
-		logger:          log.Default(),
+		logger:          slog.Default().With("agent", name),
 		backgroundJobs:  make(map[string]*Job),
 	}
 
 	for _, opt := range opts {
 		opt(a)
 	}
+	a.responseFormat = a.jsonOutputLLM.responseFormat(a.toolOrder)
 
 	return a
 }
 
-func (a *Agent) addMessage(role, content, msgType string) {
+func (a *Agent) Close() error {
+	a.jobsMu.Lock()
+	for jobID := range a.backgroundJobs {
+		a.removeJobLocked(jobID)
+	}
+	a.jobsMu.Unlock()
+
+	var errs []error
+	for _, c := range a.closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	a.closers = nil
+	return errors.Join(errs...)
+}
+
+func (a *Agent) Name() string {
+	return a.name
+}
 
+func (a *Agent) Messages() []types.Message {
 	a.mu.Lock()
+	defer a.mu.Unlock()
 
+	copied := make([]types.Message, len(a.messages))
+	copy(copied, a.messages)
+	return copied
+}
+
+func (a *Agent) Tools() []tools.Tool {
+	return a.orderedTools()
+}
+
+func (a *Agent) Model() string {
+	a.mu.Lock()
 	defer a.mu.Unlock()
+	return a.llmClient.ProviderName()
+}
+
+func (a *Agent) SetModel(name string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.llmClient.HasProvider(name) {
+		return fmt.Errorf("模型配置 '%s' 不存在", name)
+	}
+	if err := a.llmClient.CheckProvider(name); err != nil {
+		return fmt.Errorf("模型配置 '%s' 不可用: %w", name, err)
+	}
+	a.llmClient = a.llmClient.WithProvider(name)
+	a.jsonOutputLLM = NewJSONOutputLLM(a.llmClient)
+	return nil
+}
+
+func (a *Agent) Reset() {
+	a.jobsMu.Lock()
+	for jobID := range a.backgroundJobs {
+		a.removeJobLocked(jobID)
+	}
+	a.jobsMu.Unlock()
+
+	a.mu.Lock()
+	a.messages = []types.Message{}
+	strategy := a.historyStrategy
+	a.mu.Unlock()
+
+	history.Reset(strategy)
+}
+
+func (a *Agent) Jobs() []JobInfo {
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+
+	jobs := make([]JobInfo, 0, len(a.backgroundJobs))
+	for _, job := range a.backgroundJobs {
+		jobs = append(jobs, JobInfo{
+			ID:        job.ID,
+			ToolName:  job.ToolName,
+			ToolInput: job.ToolInput,
+			StartedAt: job.StartedAt,
+			Done:      len(job.ResultChan) > 0 || len(job.ErrChan) > 0,
+		})
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(jobs[j].StartedAt)
+	})
+	return jobs
+}
+
+func (a *Agent) CancelJob(jobID string) error {
+	a.jobsMu.Lock()
+	job, ok := a.backgroundJobs[jobID]
+	if ok {
+		a.removeJobLocked(jobID)
+	}
+	a.jobsMu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("后台任务 '%s' 不存在", jobID)
+	}
+
+	a.logger.Info("后台任务已取消", "tool", job.ToolName, "job_id", job.ID)
+	a.addMessageWithRecord(transcript.Record{
+		Role: "user",
+		Content: a.render(prompts.BackgroundError, prompts.JobData{
+			Tool:  job.ToolName,
+			JobID: job.ID,
+			Args:  fmt.Sprintf("%v", job.ToolInput),
+			Error: context.Canceled.Error(),
+		}),
+		Type:     "background_tool_error",
+		Tool:     job.ToolName,
+		ToolArgs: job.ToolInput,
+		Error:    context.Canceled.Error(),
+		JobID:    job.ID,
+	})
+	return nil
+}
+
+func (a *Agent) addMessage(role, content, msgType string) {
+	a.addMessageWithRecord(transcript.Record{Role: role, Content: content, Type: msgType})
+}
+
+func (a *Agent) addMessageWithRecord(rec transcript.Record) {
+
+	a.mu.Lock()
 	a.messages = append(a.messages, types.Message{
-		Role:    role,
-		Content: content,
-		Type:    msgType,
+		Role:    rec.Role,
+		Content: rec.Content,
+		Type:    rec.Type,
 	})
+	a.mu.Unlock()
+
+	a.record(rec)
+}
+
+func (a *Agent) record(rec transcript.Record) {
+	a.mu.Lock()
+	rec.Time = time.Now()
+	rec.RunID = a.runID
+	rec.ParentRunID = a.parentRunID
+	rec.Agent = a.name
+	rec.Iteration = a.iteration
+	runEvents := a.runEvents
+	a.mu.Unlock()
+
+	if a.eventHandler != nil {
+		a.eventHandler(rec)
+	}
+	if runEvents != nil {
+		runEvents(rec)
+	}
+
+	if a.transcript == nil {
+		return
+	}
+	if err := a.transcript.Write(rec); err != nil {
+		a.logger.Warn("写入 transcript 失败", "error", err)
+	}
 }
 
-func (a *Agent) Run(ctx context.Context, userInput string) (string, error) {
-	a.logger.Printf("Agent '%s' 开始运行。初始输入: %s", a.name, userInput)
+func (a *Agent) SystemPrompt() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-	fullSystemPrompt, err := a.jsonOutputLLM.buildSystemPrompt(a.systemPrompt, a.tools)
+	if a.fullPrompt != "" {
+		return a.fullPrompt, nil
+	}
+
+	systemPrompt := a.systemPrompt
+	if systemPrompt == "" {
+		systemPrompt = a.render(prompts.DefaultSystemPrompt, nil)
+	}
+
+	fullSystemPrompt, err := a.jsonOutputLLM.buildSystemPrompt(a.prompts, systemPrompt, a.orderedTools())
+	if err != nil {
+		return "", err
+	}
+	a.fullPrompt = fullSystemPrompt
+	return a.fullPrompt, nil
+}
+
+func (a *Agent) Run(ctx context.Context, userInput string) (result string, err error) {
+	runID := uuid.New().String()
+	parentRunID, _ := RunIDFromContext(ctx)
+	ctx = ContextWithRunID(ctx, runID)
+
+	ctx, runSpan := tracing.Start(ctx, "agent.run", tracing.KindInternal,
+		tracing.String("agent.name", a.name),
+		tracing.String("agent.run_id", runID),
+		tracing.String("agent.parent_run_id", parentRunID),
+	)
+	var iterSpan *tracing.Span
+
+	runEvents, _ := EventHandlerFromContext(ctx)
+
+	a.mu.Lock()
+	a.runID = runID
+	a.parentRunID = parentRunID
+	a.iteration = 0
+	a.runEvents = runEvents
+	a.mu.Unlock()
+
+	a.logger.Info("Agent 开始运行", "run_id", runID, "parent_run_id", parentRunID, "input", userInput)
+
+	a.record(transcript.Record{Type: "run_start", Content: userInput})
+
+	runStart := time.Now()
+	exhausted := false
+	defer func() {
+		rec := transcript.Record{Type: "run_end", Content: result, LatencyMS: time.Since(runStart).Milliseconds()}
+		if err != nil {
+			rec.Error = err.Error()
+		} else if exhausted {
+			rec.Status = StatusMaxIterations
+		}
+		a.record(rec)
+
+		a.mu.Lock()
+		a.runEvents = nil
+		a.mu.Unlock()
+
+		observeRun(a.name, a.iteration, err)
+
+		iterSpan.End()
+		runSpan.SetAttributes(tracing.Int("agent.iterations", a.iteration))
+		runSpan.RecordError(err)
+		runSpan.End()
+	}()
+
+	fullSystemPrompt, err := a.SystemPrompt()
 	if err != nil {
 		return "", fmt.Errorf("failed to build system prompt: %w", err)
 	}
-	a.addMessage("system", fullSystemPrompt, "system_prompt")
+
+	a.mu.Lock()
+	hasSystemPrompt := len(a.messages) > 0 && a.messages[0].Type == "system_prompt"
+	a.mu.Unlock()
+	if !hasSystemPrompt {
+		a.addMessage("system", fullSystemPrompt, "system_prompt")
+	}
 	a.addMessage("user", userInput, "user_input")
 
 	isWaitingForJobs := false
 	iterationCount := 0
 
 	for iterationCount < a.maxIterations {
-		a.logger.Printf("\n----- [Agent: %s, 迭代: %d/%d] -----\n", a.name, iterationCount+1, a.maxIterations)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+
+		a.logger.Info("开始迭代", "iteration", iterationCount+1, "max_iterations", a.maxIterations)
 
 		if injectedResult := a.checkAndInjectBackgroundJobs(); injectedResult {
-			a.logger.Println("检测到后台任务完成，注入结果。")
+			a.logger.Info("检测到后台任务完成，注入结果。")
 			isWaitingForJobs = false
 		}
 
 		if isWaitingForJobs {
-			if len(a.backgroundJobs) > 0 {
-				a.logger.Printf("正在等待 %d 个后台任务完成...", len(a.backgroundJobs))
+			if jobs := a.runningJobs(); jobs > 0 {
+				a.logger.Debug("正在等待后台任务完成...", "jobs", jobs)
 				time.Sleep(1 * time.Second)
 				continue
 			} else {
-				a.logger.Println("所有后台任务已完成。")
-				a.addMessage("user", "所有后台任务已完成，请总结结果。", "system_note")
+				a.logger.Info("所有后台任务已完成。")
+				a.addMessage("user", a.render(prompts.JobsCompleted, nil), "system_note")
 				isWaitingForJobs = false
 			}
 		}
 
 		iterationCount++
+		a.mu.Lock()
+		a.iteration = iterationCount
+		a.mu.Unlock()
+
+		iterSpan.End()
+		var iterCtx context.Context
+		iterCtx, iterSpan = tracing.Start(ctx, "agent.iteration", tracing.KindInternal,
+			tracing.String("agent.name", a.name),
+			tracing.Int("agent.iteration", iterationCount),
+		)
 
 		a.mu.Lock()
-		managedHistory := a.historyStrategy.Apply(a.messages)
+		llmClient := a.llmClient
 		a.mu.Unlock()
 
+		managedHistory, err := a.fitContext(iterCtx, llmClient)
+		if err != nil {
+			a.logger.Error("上下文超出模型窗口", "error", err)
+			return "", fmt.Errorf("iteration %d: %w", iterationCount, err)
+		}
+
 		llmMsgs := make([]llmclient.Message, len(managedHistory))
 		for i, m := range managedHistory {
 			llmMsgs[i] = llmclient.Message{Role: m.Role, Content: m.Content}
 		}
 
-		a.logger.Println("正在调用 LLM...")
-		llmResponse, err := a.llmClient.Invoke(ctx, llmMsgs, 3)
+		a.logger.Debug("正在调用 LLM...")
+		llmStart := time.Now()
+		llmCtx, llmSpan := tracing.Start(iterCtx, "llm.invoke", tracing.KindClient,
+			tracing.String("llm.provider", llmClient.ProviderName()),
+			tracing.Int("llm.messages", len(llmMsgs)),
+		)
+		llmResponse, err := llmClient.Invoke(llmclient.WithResponseFormat(llmCtx, a.responseFormat), llmMsgs)
 		if err != nil {
-			a.logger.Printf("LLM 调用错误: %v", err)
+			llmSpan.RecordError(err)
+			llmSpan.End()
+			a.logger.Error("LLM 调用错误", "error", err)
 			return "", fmt.Errorf("iteration %d: failed to get LLM response: %w", iterationCount, err)
 		}
-		a.logger.Printf("LLM 原始响应: %s", llmResponse.Content)
-		a.addMessage("assistant", llmResponse.Content, "llm_output")
+		llmSpan.SetAttributes(
+			tracing.Int("llm.usage.prompt_tokens", llmResponse.Usage.PromptTokens),
+			tracing.Int("llm.usage.completion_tokens", llmResponse.Usage.CompletionTokens),
+			tracing.Int("llm.usage.total_tokens", llmResponse.Usage.TotalTokens),
+			tracing.Bool("llm.cached", llmResponse.Cached),
+		)
+		llmSpan.End()
+		llmLatency := time.Since(llmStart)
+		a.logger.Info("LLM 原始响应", "content", llmResponse.Content, "latency", llmLatency)
 
 		action, err := a.jsonOutputLLM.parseLLMResponse(llmResponse.Content)
+
+		llmRecord := transcript.Record{
+			Role:      "assistant",
+			Content:   llmResponse.Content,
+			Type:      "llm_output",
+			LatencyMS: llmLatency.Milliseconds(),
+			Usage: &transcript.Usage{
+				PromptTokens:     llmResponse.Usage.PromptTokens,
+				CompletionTokens: llmResponse.Usage.CompletionTokens,
+				TotalTokens:      llmResponse.Usage.TotalTokens,
+			},
+		}
+		if err == nil {
+			llmRecord.Thought = action.Thought
+			llmRecord.Action = action.Action
+			llmRecord.Status = action.Status
+			llmRecord.ToolArgs = action.ActionInput
+		}
+		a.addMessageWithRecord(llmRecord)
+
 		if err != nil {
 
-			errorMsg := fmt.Sprintf("解析 LLM 响应失败: %v. 将此错误告知 LLM 并重试。", err)
-			a.logger.Printf("解析错误: %v", err)
+			errorMsg := a.render(prompts.ParseError, prompts.ErrorData{Error: err.Error()})
+			iterSpan.AddEvent("parse_error", tracing.String("error", err.Error()))
+			observeParseError(a.name)
+			a.logger.Warn("解析错误", "error", err)
 			a.addMessage("user", errorMsg, "parse_error")
 			continue
 		}
-		a.logger.Printf("解析出的动作: Action=%s, Status=%s", action.Action, action.Status)
+		a.logger.Info("解析出的动作", "action", action.Action, "status", action.Status)
+		iterSpan.SetAttributes(tracing.String("agent.action", action.Action), tracing.String("agent.status", action.Status))
 
 		if action.Status == "complete" || (action.Action == "finish" && action.Status != "continue") {
-			if len(a.backgroundJobs) > 0 {
-				a.logger.Println("Agent 想要结束，但仍有后台任务在运行。进入等待模式。")
-				a.addMessage("user", "系统提示: 你的完成请求已收到，但后台任务仍在运行。系统将等待它们完成后再生成最终摘要。要明确等待而不结束，请使用 'wait' 动作。", "system_note")
+			if a.runningJobs() > 0 {
+				a.logger.Info("Agent 想要结束，但仍有后台任务在运行。进入等待模式。")
+				a.addMessage("user", a.render(prompts.FinishPending, nil), "system_note")
 				isWaitingForJobs = true
 				continue
 			}
-			a.logger.Println("检测到 '完成' 状态。正在结束执行。")
+			a.logger.Info("检测到 '完成' 状态。正在结束执行。")
 			finalResponse, _ := action.ActionInput["final_response"].(string)
-			a.logger.Printf("最终响应: %s", finalResponse)
+			a.logger.Info("最终响应", "response", finalResponse)
 			return finalResponse, nil
 		}
 
 		if action.Action == "wait" {
-			if len(a.backgroundJobs) > 0 {
-				a.logger.Println("动作是 'wait'，且有后台任务在运行。进入等待模式。")
+			if a.runningJobs() > 0 {
+				a.logger.Info("动作是 'wait'，且有后台任务在运行。进入等待模式。")
 				isWaitingForJobs = true
 				continue
 			} else {
-				a.logger.Println("警告: Agent 选择 'wait' 动作，但没有正在运行的后台任务。")
-				a.addMessage("user", "警告: 你使用了 'wait' 动作，但没有正在运行的后台任务。请选择另一个动作或使用 'finish' 完成任务。", "system_warning")
+				a.logger.Warn("Agent 选择 'wait' 动作，但没有正在运行的后台任务。")
+				a.addMessage("user", a.render(prompts.WaitWarning, nil), "system_warning")
 				continue
 			}
 		}
@@ -204,30 +535,52 @@ func (a *Agent) Run(ctx context.Context, userInput string) (string, error) {
 		if tool, ok := a.tools[action.Action]; ok {
 
 			if runInBackground, _ := action.ActionInput["run_in_background"].(bool); runInBackground {
-				a.startBackgroundTask(ctx, tool, action.Action, action.ActionInput)
+				a.startBackgroundTask(iterCtx, tool, action.Action, action.ActionInput)
 				continue
 			}
-			a.logger.Printf("正在使用参数执行工具 '%s': %v", action.Action, action.ActionInput)
+			a.logger.Info("正在执行工具", "tool", action.Action, "args", action.ActionInput)
 
-			toolResult, err := a.executeTool(ctx, tool, action.ActionInput)
+			toolStart := time.Now()
+			toolResult, err := a.executeTool(iterCtx, tool, action.ActionInput)
 
+			toolLatency := time.Since(toolStart)
+			observeToolCall(a.name, action.Action, false, toolLatency, err)
+
+			toolRecord := transcript.Record{
+				Role:      "user",
+				Type:      "tool_result",
+				Tool:      action.Action,
+				ToolArgs:  action.ActionInput,
+				LatencyMS: toolLatency.Milliseconds(),
+			}
 			if err != nil {
-				toolResult = fmt.Sprintf("工具 '%s' 执行失败: %v", action.Action, err)
-				a.logger.Printf("工具执行错误: %s", toolResult)
+				toolResult = a.render(prompts.ToolFailed, prompts.ToolErrorData{Tool: action.Action, Error: err.Error()})
+				toolRecord.Error = err.Error()
+				a.logger.Warn("工具执行错误", "tool", action.Action, "error", err)
 			} else {
-				a.logger.Printf("工具 '%s' 执行结果: %s", action.Action, toolResult)
+				toolRecord.ToolResult = toolResult
+				a.logger.Info("工具执行结果", "tool", action.Action, "result", toolResult)
 			}
-			a.addMessage("user", toolResult, "tool_result")
+			toolRecord.Content = toolResult
+			a.addMessageWithRecord(toolRecord)
 		} else {
 
-			errorMsg := fmt.Sprintf("错误: 工具 '%s' 不存在。可用工具: %s", action.Action, strings.Join(a.getToolNames(), ", "))
-			a.logger.Println(errorMsg)
-			a.addMessage("user", errorMsg, "tool_error")
+			errorMsg := a.render(prompts.ToolNotFound, prompts.ToolErrorData{Tool: action.Action, Available: a.getToolNames()})
+			a.logger.Warn("工具不存在", "tool", action.Action)
+			observeUnknownTool(a.name)
+			a.addMessageWithRecord(transcript.Record{
+				Role:     "user",
+				Content:  errorMsg,
+				Type:     "tool_error",
+				Tool:     action.Action,
+				ToolArgs: action.ActionInput,
+			})
 		}
 	}
 
-	a.logger.Printf("已达到最大迭代次数 (%d)，但未找到答案。", a.maxIterations)
-	return "已达到最大迭代次数，但未找到答案。", nil
+	a.logger.Warn("已达到最大迭代次数，但未找到答案。", "max_iterations", a.maxIterations)
+	exhausted = true
+	return a.render(prompts.MaxIterations, nil), nil
 }
 
 func (a *Agent) startBackgroundTask(ctx context.Context, tool tools.Tool, toolName string, args map[string]interface{}) {
@@ -235,35 +588,58 @@ func (a *Agent) startBackgroundTask(ctx context.Context, tool tools.Tool, toolNa
 	defer a.jobsMu.Unlock()
 
 	jobID := uuid.New().String()
-	a.logger.Printf("启动后台任务 '%s' (ID: %s)", toolName, jobID)
+	a.logger.Info("启动后台任务", "tool", toolName, "job_id", jobID)
 
-	jobCtx, cancel := context.WithCancel(ctx)
+	parent := ctx
+	if jobsDetached(ctx) {
+		parent = context.WithoutCancel(ctx)
+	}
+	jobCtx, cancel := context.WithCancel(parent)
 
 	job := &Job{
 		ID:         jobID,
 		ToolName:   toolName,
 		ToolInput:  args,
+		StartedAt:  time.Now(),
 		ResultChan: make(chan string, 1),
 		ErrChan:    make(chan error, 1),
 		Ctx:        jobCtx,
 		CancelFunc: cancel,
 	}
 	a.backgroundJobs[jobID] = job
+	observeBackgroundJobStarted(a.name)
 
 	go func() {
 		defer close(job.ResultChan)
 		defer close(job.ErrChan)
 
-		res, err := tool.Execute(job.Ctx, args)
+		spanCtx, span := tracing.Start(job.Ctx, "tool.execute", tracing.KindInternal,
+			tracing.String("tool.name", toolName),
+			tracing.String("tool.job_id", jobID),
+			tracing.Bool("tool.background", true),
+		)
+		defer span.End()
+
+		jobStart := time.Now()
+		res, err := tool.Execute(spanCtx, args)
+		observeToolCall(a.name, toolName, true, time.Since(jobStart), err)
 		if err != nil {
+			span.RecordError(err)
 			job.ErrChan <- err
 			return
 		}
 		job.ResultChan <- res
 	}()
 
-	startMsg := fmt.Sprintf("后台任务已启动。任务名称: '%s', 任务ID: '%s'。你可以继续执行其他操作，稍后会自动收到结果。", toolName, jobID)
-	a.addMessage("user", startMsg, "tool_result")
+	startMsg := a.render(prompts.BackgroundStarted, prompts.JobData{Tool: toolName, JobID: jobID})
+	a.addMessageWithRecord(transcript.Record{
+		Role:     "user",
+		Content:  startMsg,
+		Type:     "tool_result",
+		Tool:     toolName,
+		ToolArgs: args,
+		JobID:    jobID,
+	})
 }
 
 func (a *Agent) checkAndInjectBackgroundJobs() bool {
@@ -275,17 +651,43 @@ func (a *Agent) checkAndInjectBackgroundJobs() bool {
 		select {
 		case result := <-job.ResultChan:
 
-			msg := fmt.Sprintf("后台任务 '%s' (%s) 已完成。\n参数: %v\n结果:\n%s", job.ToolName, job.ID, job.ToolInput, result)
-			a.logger.Printf("注入后台任务结果: %s", msg)
-			a.addMessage("user", msg, "background_tool_result")
-			delete(a.backgroundJobs, jobID)
+			msg := a.render(prompts.BackgroundResult, prompts.JobData{
+				Tool:   job.ToolName,
+				JobID:  job.ID,
+				Args:   fmt.Sprintf("%v", job.ToolInput),
+				Result: result,
+			})
+			a.logger.Info("注入后台任务结果", "tool", job.ToolName, "job_id", job.ID, "result", result)
+			a.addMessageWithRecord(transcript.Record{
+				Role:       "user",
+				Content:    msg,
+				Type:       "background_tool_result",
+				Tool:       job.ToolName,
+				ToolArgs:   job.ToolInput,
+				ToolResult: result,
+				JobID:      job.ID,
+			})
+			a.removeJobLocked(jobID)
 			injectedResult = true
 		case err := <-job.ErrChan:
 
-			msg := fmt.Sprintf("后台任务 '%s' (%s) 失败。\n参数: %v\n错误: %v", job.ToolName, job.ID, job.ToolInput, err)
-			a.logger.Printf("注入后台任务错误: %s", msg)
-			a.addMessage("user", msg, "background_tool_error")
-			delete(a.backgroundJobs, jobID)
+			msg := a.render(prompts.BackgroundError, prompts.JobData{
+				Tool:  job.ToolName,
+				JobID: job.ID,
+				Args:  fmt.Sprintf("%v", job.ToolInput),
+				Error: err.Error(),
+			})
+			a.logger.Warn("注入后台任务错误", "tool", job.ToolName, "job_id", job.ID, "error", err)
+			a.addMessageWithRecord(transcript.Record{
+				Role:     "user",
+				Content:  msg,
+				Type:     "background_tool_error",
+				Tool:     job.ToolName,
+				ToolArgs: job.ToolInput,
+				Error:    err.Error(),
+				JobID:    job.ID,
+			})
+			a.removeJobLocked(jobID)
 			injectedResult = true
 		default:
 
@@ -294,7 +696,32 @@ func (a *Agent) checkAndInjectBackgroundJobs() bool {
 	return injectedResult
 }
 
+func (a *Agent) runningJobs() int {
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+	return len(a.backgroundJobs)
+}
+
+func (a *Agent) removeJobLocked(jobID string) {
+	job, ok := a.backgroundJobs[jobID]
+	if !ok {
+		return
+	}
+	job.CancelFunc()
+	delete(a.backgroundJobs, jobID)
+	observeBackgroundJobFinished(a.name)
+}
+
 func (a *Agent) executeTool(ctx context.Context, tool tools.Tool, args map[string]interface{}) (string, error) {
+	ctx, span := tracing.Start(ctx, "tool.execute", tracing.KindInternal, tracing.String("tool.name", tool.Name()))
+	defer span.End()
+
+	res, err := a.runTool(ctx, tool, args)
+	span.RecordError(err)
+	return res, err
+}
+
+func (a *Agent) runTool(ctx context.Context, tool tools.Tool, args map[string]interface{}) (string, error) {
 
 	toolCtx, cancel := context.WithTimeout(ctx, 300*time.Second)
 
@@ -325,11 +752,31 @@ func (a *Agent) executeTool(ctx context.Context, tool tools.Tool, args map[strin
 	}
 }
 
-func (a *Agent) getToolNames() []string {
+func (a *Agent) render(name string, data interface{}) string {
+	text, err := a.prompts.Render(name, data)
+	if err == nil {
+		return text
+	}
+	a.logger.Error("渲染提示模板失败，回退到内置模板", "error", err)
+
+	fallback, fallbackErr := prompts.MustLoad(a.prompts.Language()).Render(name, data)
+	if fallbackErr != nil {
+		return fmt.Sprintf("%s: %v", name, data)
+	}
+	return fallback
+}
 
-	names := make([]string, 0, len(a.tools))
-	for name := range a.tools {
-		names = append(names, name)
+func (a *Agent) orderedTools() []tools.Tool {
+	ordered := make([]tools.Tool, 0, len(a.toolOrder))
+	for _, name := range a.toolOrder {
+		ordered = append(ordered, a.tools[name])
 	}
+	return ordered
+}
+
+func (a *Agent) getToolNames() []string {
+
+	names := make([]string, len(a.toolOrder))
+	copy(names, a.toolOrder)
 	return names
 }
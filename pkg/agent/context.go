@@ -0,0 +1,25 @@
+package agent
+
+import "context"
+
+type runIDKey struct{}
+
+type detachedJobsKey struct{}
+
+func ContextWithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+func ContextWithDetachedJobs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, detachedJobsKey{}, true)
+}
+
+func jobsDetached(ctx context.Context) bool {
+	detached, _ := ctx.Value(detachedJobsKey{}).(bool)
+	return detached
+}
+
+func RunIDFromContext(ctx context.Context) (string, bool) {
+	runID, ok := ctx.Value(runIDKey{}).(string)
+	return runID, ok
+}
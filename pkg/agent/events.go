@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"context"
+
+	"hivemind-go/pkg/transcript"
+)
+
+type EventHandler func(event transcript.Record)
+
+const StatusMaxIterations = "max_iterations"
+
+type eventHandlerKey struct{}
+
+func ContextWithEventHandler(ctx context.Context, handler EventHandler) context.Context {
+	return context.WithValue(ctx, eventHandlerKey{}, handler)
+}
+
+func EventHandlerFromContext(ctx context.Context) (EventHandler, bool) {
+	handler, ok := ctx.Value(eventHandlerKey{}).(EventHandler)
+	return handler, ok && handler != nil
+}
+
+func WithEventHandler(handler EventHandler) AgentOption {
+	return func(a *Agent) {
+		a.eventHandler = handler
+	}
+}
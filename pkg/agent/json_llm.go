@@ -1,11 +1,13 @@
 package agent
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	"hivemind-go/pkg/llmclient"
+	"hivemind-go/pkg/prompts"
 	"hivemind-go/pkg/tools"
 )
 
@@ -29,62 +31,23 @@ func NewJSONOutputLLM(client *llmclient.LLMClient) *JSONOutputLLM {
 	}
 }
 
-func (j *JSONOutputLLM) buildSystemPrompt(systemPrompt string, toolMap map[string]tools.Tool) (string, error) {
+func (j *JSONOutputLLM) buildSystemPrompt(promptSet *prompts.Set, systemPrompt string, toolList []tools.Tool) (string, error) {
 
-	var toolSectionBuilder strings.Builder
-	for _, tool := range toolMap {
+	data := prompts.SystemData{SystemPrompt: systemPrompt}
+	for _, tool := range toolList {
 
-		formattedTool, err := tools.FormatForPrompt(tool)
-		if err != nil {
-			return "", err
+		var indentedParams bytes.Buffer
+		if err := json.Indent(&indentedParams, tool.Parameters(), "", "  "); err != nil {
+			return "", fmt.Errorf("failed to indent parameters JSON for tool %s: %w", tool.Name(), err)
 		}
-		toolSectionBuilder.WriteString(formattedTool)
-		toolSectionBuilder.WriteString("\n\n")
+		data.Tools = append(data.Tools, prompts.ToolData{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Parameters:  indentedParams.String(),
+		})
 	}
 
-	formatSection := `{
-    "type": "object",
-    "properties": {
-        "thought": {
-            "type": "string",
-            "description": "在这里逐步思考。分析当前情况、目标、可用工具和对话历史。决定是调用工具还是使用 'finish' 动作来提供最终答案。"
-        },
-        "action": {
-            "type": "string",
-            "description": "选择下一个动作。必须是可用的工具名称之一, 'wait', 或 'finish'。"
-        },
-        "action_input": {
-            "type": "object",
-            "description": "工具调用的参数或最终响应。如果 action 是工具名称，请提供该工具所需的参数；如果是 'finish'，请使用 'final_response' 作为此处的键来提供最终响应。"
-        },
-        "status": {
-            "type": "string",
-            "enum": ["continue", "complete"],
-            "description": "如果选择了工具，则必须是 'continue'；如果选择了 'finish'，则必须是 'complete'。"
-        }
-    },
-    "required": ["thought", "action", "action_input", "status"]
-}`
-
-	finalPrompt := fmt.Sprintf(
-		`%s
-
---- 可用工具 ---
-%s
---- 后台执行与任务调度 ---
-- 在工具参数中设置 "run_in_background": true 来异步运行任务。
-- 后台任务的结果将在其完成后自动注入。
-- 当你需要等待后台结果才能继续时，请使用 'wait' 动作。
-
---- 响应格式要求 ---
-你必须严格以 JSON 格式响应。不要在 JSON 对象之外添加任何其他文本。输出一个单一的 JSON 对象，该对象必须符合以下 JSON 模式:
-%s`,
-		systemPrompt,
-		toolSectionBuilder.String(),
-		formatSection,
-	)
-
-	return finalPrompt, nil
+	return promptSet.Render(prompts.SystemPrompt, data)
 }
 
 func (j *JSONOutputLLM) parseLLMResponse(responseText string) (*LLMResponseAction, error) {
@@ -105,3 +68,41 @@ func (j *JSONOutputLLM) parseLLMResponse(responseText string) (*LLMResponseActio
 
 	return &parsedResponse, nil
 }
+
+const actionGrammarRules = `root ::= "{" ws "\"thought\"" ws ":" ws string "," ws "\"action\"" ws ":" ws action "," ws "\"action_input\"" ws ":" ws object "," ws "\"status\"" ws ":" ws status "}" ws
+status ::= ("\"continue\"" | "\"complete\"") ws
+value ::= object | array | string | number | ("true" | "false" | "null") ws
+object ::= "{" ws ( string ":" ws value ( "," ws string ":" ws value )* )? "}" ws
+array ::= "[" ws ( value ( "," ws value )* )? "]" ws
+string ::= "\"" ( [^"\\\x7F\x00-\x1F] | "\\" (["\\/bfnrt] | "u" [0-9a-fA-F]{4}) )* "\"" ws
+number ::= ("-"? ([0-9] | [1-9] [0-9]{0,15})) ("." [0-9]+)? ([eE] [-+]? [0-9]{1,15})? ws
+ws ::= | " " | "\n" [ \t]{0,20}
+`
+
+func (j *JSONOutputLLM) responseFormat(toolNames []string) llmclient.ResponseFormat {
+	actions := append([]string{"finish", "wait"}, toolNames...)
+
+	enum := make([]any, len(actions))
+	alternatives := make([]string, len(actions))
+	for i, name := range actions {
+		enum[i] = name
+		quoted, _ := json.Marshal(name)
+		literal, _ := json.Marshal(string(quoted))
+		alternatives[i] = string(literal)
+	}
+
+	return llmclient.ResponseFormat{
+		Name: "agent_action",
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"thought":      map[string]any{"type": "string"},
+				"action":       map[string]any{"type": "string", "enum": enum},
+				"action_input": map[string]any{"type": "object"},
+				"status":       map[string]any{"type": "string", "enum": []any{"continue", "complete"}},
+			},
+			"required": []any{"thought", "action", "action_input", "status"},
+		},
+		Grammar: actionGrammarRules + "action ::= (" + strings.Join(alternatives, " | ") + ") ws\n",
+	}
+}
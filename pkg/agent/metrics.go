@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"time"
+
+	"hivemind-go/pkg/metrics"
+)
+
+var iterationBuckets = []float64{1, 2, 3, 5, 8, 13, 21, 34, 55}
+
+func observeRun(agentName string, iterations int, err error) {
+	m := metrics.Default()
+
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	m.Counter("hivemind_agent_runs_total", "Agent runs by outcome.", "agent", "outcome").
+		Add(1, agentName, outcome)
+	m.Histogram("hivemind_agent_iterations", "Iterations used per agent run.", iterationBuckets, "agent").
+		Observe(float64(iterations), agentName)
+}
+
+func observeParseError(agentName string) {
+	metrics.Default().Counter("hivemind_agent_parse_errors_total", "LLM responses that could not be parsed into an action.", "agent").
+		Add(1, agentName)
+}
+
+func observeToolCall(agentName, toolName string, background bool, elapsed time.Duration, err error) {
+	m := metrics.Default()
+
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+		m.Counter("hivemind_tool_failures_total", "Tool executions that returned an error.", "agent", "tool").
+			Add(1, agentName, toolName)
+	}
+	mode := "foreground"
+	if background {
+		mode = "background"
+	}
+	m.Counter("hivemind_tool_calls_total", "Tool executions by outcome and mode.", "agent", "tool", "mode", "outcome").
+		Add(1, agentName, toolName, mode, outcome)
+	m.Histogram("hivemind_tool_duration_seconds", "Tool execution latency.", metrics.DefaultBuckets, "agent", "tool", "mode").
+		Observe(elapsed.Seconds(), agentName, toolName, mode)
+}
+
+func observeUnknownTool(agentName string) {
+	metrics.Default().Counter("hivemind_tool_unknown_total", "Actions that referenced a tool the agent does not have.", "agent").
+		Add(1, agentName)
+}
+
+func observeBackgroundJobStarted(agentName string) {
+	m := metrics.Default()
+	m.Counter("hivemind_background_jobs_started_total", "Background jobs started.", "agent").
+		Add(1, agentName)
+	m.Gauge("hivemind_background_jobs_running", "Background jobs currently running.", "agent").
+		Add(1, agentName)
+}
+
+func observeBackgroundJobFinished(agentName string) {
+	metrics.Default().Gauge("hivemind_background_jobs_running", "Background jobs currently running.", "agent").
+		Add(-1, agentName)
+}
+
+func observeContextOverflow(agentName, strategy string) {
+	metrics.Default().Counter("hivemind_agent_context_overflows_total", "Iterations whose history exceeded the model context window.", "agent", "strategy").
+		Add(1, agentName, strategy)
+}
@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hivemind-go/pkg/history"
+	"hivemind-go/pkg/llmclient"
+	"hivemind-go/pkg/prompts"
+	"hivemind-go/pkg/transcript"
+	"hivemind-go/pkg/types"
+)
+
+type OverflowStrategy string
+
+const (
+	OverflowTruncate  OverflowStrategy = "truncate"
+	OverflowSummarize OverflowStrategy = "summarize"
+	OverflowFail      OverflowStrategy = "fail"
+)
+
+var ErrContextOverflow = errors.New("context window exceeded")
+
+const maxSummaryRounds = 3
+
+func WithOverflowStrategy(strategy OverflowStrategy) AgentOption {
+	return func(a *Agent) {
+		if strategy != "" {
+			a.overflow = strategy
+		}
+	}
+}
+
+func (a *Agent) fitContext(ctx context.Context, llmClient *llmclient.LLMClient) ([]types.Message, error) {
+	managed := a.managedHistory(ctx)
+	budget, ok := llmClient.ContextBudget()
+	if !ok {
+		return managed, nil
+	}
+	tokens := estimateTokens(managed)
+	if tokens <= budget {
+		return managed, nil
+	}
+
+	a.logger.Warn("上下文超出模型窗口", "estimated_tokens", tokens, "budget", budget, "strategy", a.overflow)
+	observeContextOverflow(a.name, string(a.overflow))
+	overflowErr := fmt.Errorf("%w: estimated %d tokens, model '%s' allows %d", ErrContextOverflow, tokens, llmClient.ProviderName(), budget)
+
+	switch a.overflow {
+	case OverflowFail:
+		a.recordOverflow(tokens, budget, 0)
+		return nil, overflowErr
+	case OverflowSummarize:
+		for round := 0; round < maxSummaryRounds; round++ {
+			if err := a.summarizeHistory(ctx, llmClient, managed, tokens-budget, budget); err != nil {
+				a.logger.Warn("历史摘要失败，改为截断", "error", err)
+				break
+			}
+			previous := tokens
+			managed = a.managedHistory(ctx)
+			if tokens = estimateTokens(managed); tokens <= budget {
+				return managed, nil
+			}
+			if tokens >= previous {
+				a.logger.Warn("摘要没有减少上下文，改为截断", "estimated_tokens", tokens)
+				break
+			}
+		}
+	}
+
+	truncated := truncateHistory(managed, budget)
+	dropped := len(managed) - len(truncated)
+	a.recordOverflow(tokens, budget, dropped)
+	if estimateTokens(truncated) > budget {
+		return nil, overflowErr
+	}
+	a.logger.Info("已截断历史以适应上下文", "dropped", dropped, "estimated_tokens", estimateTokens(truncated))
+	return truncated, nil
+}
+
+func (a *Agent) managedHistory(ctx context.Context) []types.Message {
+	a.mu.Lock()
+	snapshot := append([]types.Message(nil), a.messages...)
+	strategy := a.historyStrategy
+	a.mu.Unlock()
+	return history.Apply(ctx, strategy, snapshot)
+}
+
+func (a *Agent) recordOverflow(tokens, budget, dropped int) {
+	a.record(transcript.Record{
+		Type:    "context_overflow",
+		Status:  string(a.overflow),
+		Content: fmt.Sprintf("estimated %d tokens, budget %d, dropped %d messages", tokens, budget, dropped),
+	})
+}
+
+func truncateHistory(messages []types.Message, budget int) []types.Message {
+	current := lastIndexOfType(messages, "user_input")
+	drop := make([]bool, len(messages))
+	tokens := estimateTokens(messages)
+	for i := 0; i < len(messages)-1 && tokens > budget; i++ {
+		if messages[i].Type == "system_prompt" || messages[i].Type == "history_summary" || i == current {
+			continue
+		}
+		drop[i] = true
+		tokens -= estimateTokens(messages[i : i+1])
+	}
+
+	result := make([]types.Message, 0, len(messages))
+	for i, m := range messages {
+		if !drop[i] {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func (a *Agent) summarizeHistory(ctx context.Context, llmClient *llmclient.LLMClient, managed []types.Message, excess, budget int) error {
+	a.mu.Lock()
+	snapshot := append([]types.Message(nil), a.messages...)
+	a.mu.Unlock()
+
+	positions := matchMessages(snapshot, managed)
+	current := lastIndexOfType(managed, "user_input")
+	target := excess + budget/10
+	limit := budget / 2
+
+	var selected []int
+	data := prompts.HistoryData{}
+	freed := 0
+	for i := 0; i < len(managed)-2 && freed < target; i++ {
+		m := managed[i]
+		if m.Type == "system_prompt" || i == current || positions[i] < 0 {
+			continue
+		}
+		cost := estimateTokens(managed[i : i+1])
+		if freed+cost > limit {
+			break
+		}
+		selected = append(selected, positions[i])
+		data.Entries = append(data.Entries, prompts.HistoryEntry{Role: m.Role, Type: m.Type, Content: m.Content})
+		freed += cost
+	}
+	if len(selected) < 2 {
+		return fmt.Errorf("not enough history to summarize")
+	}
+
+	request := a.render(prompts.SummarizeHistory, data)
+	resp, err := llmClient.Invoke(ctx, []llmclient.Message{{Role: "user", Content: request}})
+	if err != nil {
+		return err
+	}
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return fmt.Errorf("empty summary")
+	}
+	content := a.render(prompts.HistorySummary, prompts.SummaryData{Summary: summary, Count: len(selected)})
+
+	a.mu.Lock()
+	if len(a.messages) < len(snapshot) {
+		a.mu.Unlock()
+		return fmt.Errorf("history changed while summarizing")
+	}
+	for _, i := range selected {
+		if a.messages[i] != snapshot[i] {
+			a.mu.Unlock()
+			return fmt.Errorf("history changed while summarizing")
+		}
+	}
+	replaced := make(map[int]bool, len(selected))
+	for _, i := range selected {
+		replaced[i] = true
+	}
+	messages := make([]types.Message, 0, len(a.messages)-len(selected)+1)
+	for i, m := range a.messages {
+		if i == selected[0] {
+			messages = append(messages, types.Message{Role: "user", Content: content, Type: "history_summary"})
+		}
+		if !replaced[i] {
+			messages = append(messages, m)
+		}
+	}
+	a.messages = messages
+	a.mu.Unlock()
+
+	a.logger.Info("已将较早的历史压缩为摘要", "messages", len(selected), "freed_tokens", freed)
+	a.record(transcript.Record{Role: "user", Content: content, Type: "history_summary"})
+	return nil
+}
+
+func matchMessages(raw, managed []types.Message) []int {
+	positions := make([]int, len(managed))
+	next := 0
+	for i, m := range managed {
+		positions[i] = -1
+		for j := next; j < len(raw); j++ {
+			if raw[j] == m {
+				positions[i] = j
+				next = j + 1
+				break
+			}
+		}
+	}
+	return positions
+}
+
+func lastIndexOfType(messages []types.Message, msgType string) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Type == msgType {
+			return i
+		}
+	}
+	return -1
+}
+
+func estimateTokens(messages []types.Message) int {
+	converted := make([]llmclient.Message, len(messages))
+	for i, m := range messages {
+		converted[i] = llmclient.Message{Role: m.Role, Content: m.Content}
+	}
+	return llmclient.EstimateTokens(converted)
+}
@@ -0,0 +1,234 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"hivemind-go/pkg/llmclient"
+	"hivemind-go/pkg/types"
+)
+
+func msg(msgType, content string) types.Message {
+	return types.Message{Role: "user", Type: msgType, Content: content}
+}
+
+func contents(messages []types.Message) []string {
+	out := make([]string, len(messages))
+	for i, m := range messages {
+		out[i] = m.Content
+	}
+	return out
+}
+
+func newStubClient(t *testing.T, contextLength int, handler func()) *llmclient.LLMClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler != nil {
+			handler()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content":[{"type":"text","text":"summary"}],"stop_reason":"end_turn"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	config := &llmclient.AppConfig{}
+	config.Common.ActiveModel = "stub"
+	config.Providers = map[string]llmclient.ProviderConfig{
+		"stub": {
+			Name:          "stub",
+			Type:          llmclient.ProviderAnthropic,
+			Model:         "stub-model",
+			APIKey:        "test-key",
+			BaseURL:       srv.URL,
+			MaxTokens:     100,
+			ContextLength: contextLength,
+			Retry:         &llmclient.RetryPolicy{MaxAttempts: 1},
+		},
+	}
+	return llmclient.NewLLMClient(config)
+}
+
+func TestTruncateHistory(t *testing.T) {
+	long := strings.Repeat("x", 396)
+	history := []types.Message{
+		msg("system_prompt", "sys"),
+		msg("user_input", "old task"),
+		msg("llm_output", long),
+		msg("history_summary", "summary"),
+		msg("user_input", "task"),
+		msg("llm_output", long),
+		msg("tool_result", "last"),
+	}
+
+	tests := []struct {
+		name   string
+		budget int
+		want   []string
+	}{
+		{name: "fits", budget: 1000, want: contents(history)},
+		{name: "drops oldest first", budget: 150, want: []string{"sys", "summary", "task", long, "last"}},
+		{name: "keeps pinned and last message", budget: 1, want: []string{"sys", "summary", "task", "last"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contents(truncateHistory(history, tt.budget)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("truncateHistory = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchMessages(t *testing.T) {
+	raw := []types.Message{msg("user_input", "a"), msg("llm_output", "b"), msg("llm_output", "b"), msg("tool_result", "c")}
+
+	tests := []struct {
+		name    string
+		managed []types.Message
+		want    []int
+	}{
+		{name: "identity", managed: raw, want: []int{0, 1, 2, 3}},
+		{name: "skipped messages", managed: []types.Message{raw[0], raw[3]}, want: []int{0, 3}},
+		{name: "duplicates match in order", managed: []types.Message{raw[1], raw[1]}, want: []int{1, 2}},
+		{name: "injected messages", managed: []types.Message{raw[0], msg("retrieved_history", "r"), raw[3]}, want: []int{0, -1, 3}},
+		{name: "no reordering", managed: []types.Message{raw[3], raw[0]}, want: []int{3, -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchMessages(raw, tt.managed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchMessages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeHistory(t *testing.T) {
+	long := strings.Repeat("x", 396)
+	history := []types.Message{
+		msg("system_prompt", "sys"),
+		msg("user_input", "task"),
+		msg("llm_output", long+"1"),
+		msg("tool_result", long+"2"),
+		msg("llm_output", long+"3"),
+		msg("tool_result", long+"4"),
+		msg("llm_output", "out"),
+		msg("tool_result", "last"),
+	}
+
+	tests := []struct {
+		name    string
+		history []types.Message
+		mutate  func(a *Agent)
+		wantErr string
+		want    []string
+	}{
+		{
+			name:    "replaces the oldest messages",
+			history: history,
+			want:    []string{"sys", "task", "summary", long + "4", "out", "last"},
+		},
+		{
+			name:    "appended messages are kept",
+			history: history,
+			mutate:  func(a *Agent) { a.messages = append(a.messages, msg("tool_result", "new")) },
+			want:    []string{"sys", "task", "summary", long + "4", "out", "last", "new"},
+		},
+		{
+			name:    "history changed while summarizing",
+			history: history,
+			mutate:  func(a *Agent) { a.messages[3] = msg("tool_result", "edited") },
+			wantErr: "history changed while summarizing",
+		},
+		{
+			name:    "history shrank while summarizing",
+			history: history,
+			mutate:  func(a *Agent) { a.messages = a.messages[:2] },
+			wantErr: "history changed while summarizing",
+		},
+		{
+			name:    "not enough history",
+			history: history[:4],
+			wantErr: "not enough history to summarize",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a *Agent
+			client := newStubClient(t, 0, func() {
+				if tt.mutate != nil {
+					a.mu.Lock()
+					tt.mutate(a)
+					a.mu.Unlock()
+				}
+			})
+			a = NewAgent("test", client)
+			a.messages = append([]types.Message(nil), tt.history...)
+			before := a.Messages()
+
+			err := a.summarizeHistory(context.Background(), client, before, 150, 1000)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := a.Messages()
+			if got[2].Type != "history_summary" || !strings.Contains(got[2].Content, "summary") {
+				t.Errorf("message 2 = %+v, want history_summary", got[2])
+			}
+			got[2].Content = "summary"
+			if !reflect.DeepEqual(contents(got), tt.want) {
+				t.Errorf("messages = %v, want %v", contents(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestFitContext(t *testing.T) {
+	long := strings.Repeat("x", 396)
+	history := []types.Message{
+		msg("system_prompt", "sys"),
+		msg("user_input", "task"),
+		msg("llm_output", long),
+		msg("tool_result", long),
+		msg("llm_output", "out"),
+	}
+
+	tests := []struct {
+		name          string
+		contextLength int
+		strategy      OverflowStrategy
+		wantErr       error
+		want          int
+	}{
+		{name: "unknown window", strategy: OverflowFail, want: 5},
+		{name: "fits", contextLength: 1000, strategy: OverflowFail, want: 5},
+		{name: "fail", contextLength: 200, strategy: OverflowFail, wantErr: ErrContextOverflow},
+		{name: "truncate", contextLength: 250, strategy: OverflowTruncate, want: 4},
+		{name: "summarize falls back to truncate", contextLength: 250, strategy: OverflowSummarize, want: 4},
+		{name: "nothing left to drop", contextLength: 101, strategy: OverflowTruncate, wantErr: ErrContextOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newStubClient(t, tt.contextLength, nil)
+			a := NewAgent("test", client, WithOverflowStrategy(tt.strategy))
+			a.messages = append([]types.Message(nil), history...)
+
+			got, err := a.fitContext(context.Background(), client)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && len(got) != tt.want {
+				t.Errorf("fitContext kept %d messages, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
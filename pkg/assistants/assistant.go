@@ -9,21 +9,29 @@ import (
 	"sync"
 
 	"hivemind-go/pkg/builder"
-	"hivemind-go/pkg/llmclient"
 	"hivemind-go/pkg/tools"
 )
 
+func init() {
+	builder.RegisterAssistant(builder.DefaultRegistry, "TaskDelegator", func(env builder.BuildEnv, _ builder.NoOptions) (tools.Tool, error) {
+		return NewTaskDelegator(env), nil
+	})
+	builder.RegisterAssistant(builder.DefaultRegistry, "ParallelTaskDelegator", func(env builder.BuildEnv, _ builder.NoOptions) (tools.Tool, error) {
+		return NewParallelTaskDelegator(env), nil
+	})
+}
+
 type TaskDelegator struct {
 	baseCtx        *tools.Context
-	llmClient      *llmclient.LLMClient
+	env            builder.BuildEnv
 	subAgentConfig *builder.AgentConfig
 }
 
-func NewTaskDelegator(ctx *tools.Context, client *llmclient.LLMClient, cfg *builder.AgentConfig) tools.Tool {
+func NewTaskDelegator(env builder.BuildEnv) tools.Tool {
 	return &TaskDelegator{
-		baseCtx:        ctx,
-		llmClient:      client,
-		subAgentConfig: cfg,
+		baseCtx:        env.Ctx,
+		env:            env,
+		subAgentConfig: env.SubAgent,
 	}
 }
 
@@ -66,11 +74,12 @@ func (t *TaskDelegator) Execute(ctx context.Context, args map[string]interface{}
 		return "", fmt.Errorf("创建子 agent 上下文失败: %w", err)
 	}
 
-	subAgent, err := builder.BuildAgent(t.subAgentConfig, t.llmClient, subAgentCtx)
+	subAgent, err := t.env.BuildSubAgent(t.subAgentConfig, subAgentCtx)
 	if err != nil {
 
 		return "", fmt.Errorf("构建子 agent 失败: %w", err)
 	}
+	defer subAgent.Close()
 
 	result, err := subAgent.Run(ctx, taskDesc)
 	if err != nil {
@@ -82,15 +91,15 @@ func (t *TaskDelegator) Execute(ctx context.Context, args map[string]interface{}
 
 type ParallelTaskDelegator struct {
 	baseCtx        *tools.Context
-	llmClient      *llmclient.LLMClient
+	env            builder.BuildEnv
 	subAgentConfig *builder.AgentConfig
 }
 
-func NewParallelTaskDelegator(ctx *tools.Context, client *llmclient.LLMClient, cfg *builder.AgentConfig) tools.Tool {
+func NewParallelTaskDelegator(env builder.BuildEnv) tools.Tool {
 	return &ParallelTaskDelegator{
-		baseCtx:        ctx,
-		llmClient:      client,
-		subAgentConfig: cfg,
+		baseCtx:        env.Ctx,
+		env:            env,
+		subAgentConfig: env.SubAgent,
 	}
 }
 
@@ -156,11 +165,12 @@ func (p *ParallelTaskDelegator) Execute(ctx context.Context, args map[string]int
 			parallelSubAgentConfig := *p.subAgentConfig
 			parallelSubAgentConfig.Name = fmt.Sprintf("%s_task_%d", p.subAgentConfig.Name, index)
 
-			subAgent, err := builder.BuildAgent(&parallelSubAgentConfig, p.llmClient, subAgentCtx)
+			subAgent, err := p.env.BuildSubAgent(&parallelSubAgentConfig, subAgentCtx)
 			if err != nil {
 				errs[index] = fmt.Errorf("任务 #%d: 构建 agent 失败: %w", index, err)
 				return
 			}
+			defer subAgent.Close()
 
 			result, err := subAgent.Run(ctx, description)
 			if err != nil {
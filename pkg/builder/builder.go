@@ -3,25 +3,23 @@ package builder
 import (
 	"fmt"
 	"io"
-	"log"
-	"os"
 	"path/filepath"
-	"reflect"
 	"regexp"
 
 	"hivemind-go/pkg/agent"
 	"hivemind-go/pkg/llmclient"
+	"hivemind-go/pkg/logging"
+	"hivemind-go/pkg/prompts"
 	"hivemind-go/pkg/tools"
+	"hivemind-go/pkg/transcript"
 )
 
-type ToolConfig interface{}
+type ToolSpec struct {
+	Name string
 
-type AssistantConstructor func(ctx *tools.Context, client *llmclient.LLMClient, cfg *AgentConfig) tools.Tool
+	Options Options
 
-type AssistantConfig struct {
-	Constructor AssistantConstructor
-
-	SubAgentConfig *AgentConfig
+	SubAgent *AgentConfig
 }
 
 type AgentConfig struct {
@@ -29,10 +27,32 @@ type AgentConfig struct {
 	SystemPrompt  string
 	MaxIterations int
 
-	Tools []ToolConfig
+	MaxDelegationDepth int
+
+	Model string
+
+	Prompts *prompts.Set
+
+	Logging *logging.Config
+
+	History *HistoryConfig
+
+	Overflow string
+
+	Tools []ToolSpec
 }
 
-func setupLogger(agentName string, ctx *tools.Context) (*log.Logger, error) {
+func setupLogger(agentName string, config *logging.Config, ctx *tools.Context) (*logging.Sink, error) {
+
+	if config == nil {
+		if inherited, ok := ctx.Get("logging_config"); ok {
+			config, _ = inherited.(*logging.Config)
+		}
+	}
+	if config == nil {
+		config = logging.DefaultConfig()
+	}
+	ctx.Set("logging_config", config, true)
 
 	sanitizedAgentName := regexp.MustCompile(`[<>:"/\\|?*\s.]`).ReplaceAllString(agentName, "_")
 	var logDir string
@@ -41,86 +61,115 @@ func setupLogger(agentName string, ctx *tools.Context) (*log.Logger, error) {
 
 		subagentsDir := filepath.Join(parentLogDir, "subagents")
 		logDir = filepath.Join(subagentsDir, sanitizedAgentName+"_logs")
-	} else {
+	} else if config.Dir != "" {
 
-		logDir = filepath.Join("output", sanitizedAgentName+"_logs")
+		logDir = filepath.Join(config.Dir, sanitizedAgentName+"_logs")
 	}
 
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return nil, fmt.Errorf("无法创建日志目录 %s: %w", logDir, err)
+	if logDir != "" {
+		ctx.Set("agent_log_dir", logDir, false)
 	}
 
-	ctx.Set("agent_log_dir", logDir, false)
-
-	logFilePath := filepath.Join(logDir, "messages.log")
-
-	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	sink, err := config.Open(logDir)
 	if err != nil {
-		return nil, fmt.Errorf("无法打开日志文件 %s: %w", logFilePath, err)
+		return nil, fmt.Errorf("无法打开日志输出 %s: %w", logDir, err)
 	}
-
-	multiWriter := io.MultiWriter(os.Stdout, logFile)
-
-	logger := log.New(multiWriter, fmt.Sprintf("[%s] ", agentName), log.LstdFlags)
-	return logger, nil
+	sink.Logger = sink.Logger.With("agent", agentName)
+	return sink, nil
 }
 
 func BuildAgent(config *AgentConfig, llmClient *llmclient.LLMClient, baseCtx *tools.Context) (*agent.Agent, error) {
-	logger, err := setupLogger(config.Name, baseCtx)
-	if err != nil {
-		return nil, fmt.Errorf("设置 logger 失败: %w", err)
-	}
-
-	var agentTools []tools.Tool
+	return DefaultRegistry.BuildAgent(config, llmClient, baseCtx)
+}
 
-	for _, tConf := range config.Tools {
+func (r *Registry) BuildAgent(config *AgentConfig, llmClient *llmclient.LLMClient, baseCtx *tools.Context) (*agent.Agent, error) {
+	return r.buildAgent(config, llmClient, baseCtx, false)
+}
 
-		switch v := tConf.(type) {
+func (e BuildEnv) BuildSubAgent(config *AgentConfig, ctx *tools.Context) (*agent.Agent, error) {
+	return e.Registry.buildAgent(config, e.LLMClient, ctx, e.validated)
+}
 
-		case tools.Tool:
-			agentTools = append(agentTools, v)
+func (r *Registry) buildAgent(config *AgentConfig, llmClient *llmclient.LLMClient, baseCtx *tools.Context, validated bool) (*agent.Agent, error) {
+	if !validated {
+		if err := r.Validate(config, llmClient); err != nil {
+			return nil, fmt.Errorf("agent 配置校验失败:\n%w", err)
+		}
+	}
 
-		case reflect.Type:
+	sink, err := setupLogger(config.Name, config.Logging, baseCtx)
+	if err != nil {
+		return nil, fmt.Errorf("设置 logger 失败: %w", err)
+	}
+	closers := []io.Closer{sink}
+	fail := func(err error) (*agent.Agent, error) {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i].Close()
+		}
+		return nil, err
+	}
 
-			instance, ok := reflect.New(v).Interface().(tools.Tool)
-			if !ok {
-				return nil, fmt.Errorf("类型 %v 没有实现 tools.Tool 接口", v)
-			}
+	if config.Model != "" {
+		llmClient = llmClient.WithProvider(config.Model)
+	}
 
-			if sc, ok := instance.(interface{ SetContext(*tools.Context) }); ok {
-				sc.SetContext(baseCtx)
-			}
-			agentTools = append(agentTools, instance)
+	env := BuildEnv{
+		Ctx:       baseCtx,
+		LLMClient: llmClient,
+		Registry:  r,
+		validated: true,
+	}
 
-		case AssistantConfig:
-			assistant, err := buildAssistant(v, llmClient, baseCtx)
-			if err != nil {
-				return nil, fmt.Errorf("构建 assistant 工具失败: %w", err)
-			}
-			agentTools = append(agentTools, assistant)
+	var agentTools []tools.Tool
 
-		default:
-			return nil, fmt.Errorf("不支持的工具配置类型: %T", tConf)
+	for _, spec := range config.Tools {
+		tool, err := r.Build(spec, env)
+		if err != nil {
+			return fail(fmt.Errorf("agent '%s': %w", config.Name, err))
 		}
+		if closer, ok := tool.(io.Closer); ok {
+			closers = append(closers, closer)
+		}
+		agentTools = append(agentTools, tool)
 	}
 
-	agentInstance := agent.NewAgent(
-		config.Name,
-		llmClient,
+	opts := []agent.AgentOption{
 		agent.WithSystemPrompt(config.SystemPrompt),
-		agent.WithMaxIterations(config.MaxIterations),
 		agent.WithTools(agentTools...),
-		agent.WithLogger(logger),
-	)
-
-	return agentInstance, nil
-}
+		agent.WithLogger(sink.Logger),
+	}
+	if sink.Dir != "" {
+		transcriptWriter, err := transcript.OpenFile(filepath.Join(sink.Dir, transcript.FileName))
+		if err != nil {
+			return fail(fmt.Errorf("打开 transcript 文件失败: %w", err))
+		}
+		opts = append(opts, agent.WithTranscript(transcriptWriter))
+		closers = append(closers, transcriptWriter)
+	}
+	if config.MaxIterations > 0 {
+		opts = append(opts, agent.WithMaxIterations(config.MaxIterations))
+	}
+	if config.Prompts != nil {
+		opts = append(opts, agent.WithPrompts(config.Prompts))
+	}
+	if config.History != nil {
+		strategy, err := config.History.build(llmClient, config.Prompts)
+		if err != nil {
+			return fail(fmt.Errorf("agent '%s': %w", config.Name, err))
+		}
+		opts = append(opts, agent.WithHistoryStrategy(strategy))
+	}
+	if config.Overflow != "" {
+		if err := validateOverflow(config.Overflow); err != nil {
+			return fail(fmt.Errorf("agent '%s': %w", config.Name, err))
+		}
+		opts = append(opts, agent.WithOverflowStrategy(agent.OverflowStrategy(config.Overflow)))
+	}
 
-func buildAssistant(config AssistantConfig, llmClient *llmclient.LLMClient, baseCtx *tools.Context) (tools.Tool, error) {
-	if config.Constructor == nil {
-		return nil, fmt.Errorf("assistant config 缺少构造函数")
+	for _, closer := range closers {
+		opts = append(opts, agent.WithCloser(closer))
 	}
+	agentInstance := agent.NewAgent(config.Name, llmClient, opts...)
 
-	assistant := config.Constructor(baseCtx, llmClient, config.SubAgentConfig)
-	return assistant, nil
+	return agentInstance, nil
 }
@@ -0,0 +1,346 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+
+	"hivemind-go/pkg/agent"
+	"hivemind-go/pkg/llmclient"
+	"hivemind-go/pkg/logging"
+	"hivemind-go/pkg/prompts"
+	"hivemind-go/pkg/tools"
+	"hivemind-go/pkg/tracing"
+)
+
+type ToolDefinition struct {
+	Name string `mapstructure:"name"`
+
+	Options Options `mapstructure:"options"`
+}
+
+type DelegateDefinition struct {
+	Tool string `mapstructure:"tool"`
+
+	Agent string `mapstructure:"agent"`
+
+	Options Options `mapstructure:"options"`
+}
+
+type AgentDefinition struct {
+	Name string `mapstructure:"name"`
+
+	SystemPrompt     string `mapstructure:"system_prompt"`
+	SystemPromptFile string `mapstructure:"system_prompt_file"`
+
+	MaxIterations int `mapstructure:"max_iterations"`
+
+	MaxDelegationDepth int `mapstructure:"max_delegation_depth"`
+
+	Model string `mapstructure:"model"`
+
+	Language  string `mapstructure:"language"`
+	PromptDir string `mapstructure:"prompt_dir"`
+
+	History *HistoryConfig `mapstructure:"history"`
+
+	Overflow string `mapstructure:"overflow"`
+
+	Tools []ToolDefinition `mapstructure:"tools"`
+
+	Delegates []DelegateDefinition `mapstructure:"delegates"`
+}
+
+type LoggingDefinition struct {
+	Level string `mapstructure:"level"`
+
+	Dir string `mapstructure:"dir"`
+
+	Stdout *bool `mapstructure:"stdout"`
+
+	JSON bool `mapstructure:"json"`
+
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+
+	MaxBackups int `mapstructure:"max_backups"`
+}
+
+type Definitions struct {
+	DefaultAgent string `mapstructure:"default_agent"`
+
+	Logging *LoggingDefinition `mapstructure:"logging"`
+
+	Tracing *tracing.Config `mapstructure:"tracing"`
+
+	Agents []AgentDefinition `mapstructure:"agents"`
+
+	baseDir string
+}
+
+func LoadDefinitions(path string) (*Definitions, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		v.SetConfigType("yaml")
+	default:
+		v.SetConfigType("toml")
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("无法读取 agent 定义文件 %s: %w", path, err)
+	}
+
+	var defs Definitions
+	hook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		toolNameHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))
+	if err := v.Unmarshal(&defs, hook); err != nil {
+		return nil, fmt.Errorf("无法解析 agent 定义文件 %s: %w", path, err)
+	}
+	defs.baseDir = filepath.Dir(path)
+
+	return &defs, nil
+}
+
+func (d *Definitions) Names() []string {
+	names := make([]string, 0, len(d.Agents))
+	for _, def := range d.Agents {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+func (d *Definitions) AgentConfigs(registry *Registry) (map[string]*AgentConfig, error) {
+	configs, errs := d.agentConfigs(registry)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return configs, nil
+}
+
+func (d *Definitions) agentConfigs(registry *Registry) (map[string]*AgentConfig, []error) {
+	configs := make(map[string]*AgentConfig, len(d.Agents))
+	var errs []error
+
+	loggingConfig, err := d.loggingConfig()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, def := range d.Agents {
+		if def.Name == "" {
+			errs = append(errs, fmt.Errorf("agent 定义缺少 name"))
+			continue
+		}
+		if _, exists := configs[def.Name]; exists {
+			errs = append(errs, fmt.Errorf("agent '%s' 重复定义", def.Name))
+			continue
+		}
+
+		cfg, err := d.baseConfig(def)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("agent '%s': %w", def.Name, err))
+			continue
+		}
+		cfg.Logging = loggingConfig
+		configs[def.Name] = cfg
+	}
+
+	for _, def := range d.Agents {
+		cfg, ok := configs[def.Name]
+		if !ok {
+			continue
+		}
+
+		for _, toolDef := range def.Tools {
+			spec := ToolSpec{Name: toolDef.Name, Options: toolDef.Options}
+			if err := registry.CheckSpec(spec); err != nil {
+				errs = append(errs, fmt.Errorf("agent '%s': %w", def.Name, err))
+				continue
+			}
+			cfg.Tools = append(cfg.Tools, spec)
+		}
+
+		for _, delegate := range def.Delegates {
+			subConfig, ok := configs[delegate.Agent]
+			if !ok {
+				errs = append(errs, fmt.Errorf("agent '%s': 委托目标 agent '%s' 未定义", def.Name, delegate.Agent))
+				continue
+			}
+			spec := ToolSpec{Name: delegate.Tool, Options: delegate.Options, SubAgent: subConfig}
+			if err := registry.CheckSpec(spec); err != nil {
+				errs = append(errs, fmt.Errorf("agent '%s': %w", def.Name, err))
+				continue
+			}
+			cfg.Tools = append(cfg.Tools, spec)
+		}
+	}
+
+	return configs, errs
+}
+
+func (d *Definitions) AgentConfig(name string, registry *Registry) (*AgentConfig, error) {
+	if name == "" {
+		name = d.DefaultAgent
+	}
+	if name == "" {
+		return nil, fmt.Errorf("未指定 agent 名称，且定义文件中没有 default_agent")
+	}
+
+	configs, err := d.AgentConfigs(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, ok := configs[name]
+	if !ok {
+		return nil, fmt.Errorf("agent '%s' 未定义", name)
+	}
+	return cfg, nil
+}
+
+func (d *Definitions) Validate(registry *Registry, llmClient *llmclient.LLMClient) error {
+	configs, errs := d.agentConfigs(registry)
+
+	seen := make(map[string]bool)
+	for _, e := range errs {
+		seen[e.Error()] = true
+	}
+	for _, name := range d.Names() {
+		config, ok := configs[name]
+		if !ok {
+			continue
+		}
+		if err := registry.Validate(config, llmClient); err != nil {
+			for _, e := range unwrapJoined(err) {
+				if !seen[e.Error()] {
+					seen[e.Error()] = true
+					errs = append(errs, e)
+				}
+			}
+		}
+	}
+
+	if d.DefaultAgent != "" {
+		if _, ok := configs[d.DefaultAgent]; !ok {
+			errs = append(errs, fmt.Errorf("default_agent '%s' 未定义", d.DefaultAgent))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func unwrapJoined(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
+func (d *Definitions) baseConfig(def AgentDefinition) (*AgentConfig, error) {
+	systemPrompt := def.SystemPrompt
+	if def.SystemPromptFile != "" {
+		content, err := os.ReadFile(d.resolvePath(def.SystemPromptFile))
+		if err != nil {
+			return nil, fmt.Errorf("无法读取系统提示文件: %w", err)
+		}
+		systemPrompt = string(content)
+	}
+
+	cfg := &AgentConfig{
+		Name:               def.Name,
+		SystemPrompt:       systemPrompt,
+		MaxIterations:      def.MaxIterations,
+		MaxDelegationDepth: def.MaxDelegationDepth,
+		Model:              def.Model,
+		History:            def.History,
+		Overflow:           def.Overflow,
+	}
+
+	if def.Language != "" || def.PromptDir != "" {
+		promptSet, err := prompts.Load(def.Language)
+		if err != nil {
+			return nil, err
+		}
+		if def.PromptDir != "" {
+			if err := promptSet.OverrideFromDir(d.resolvePath(def.PromptDir)); err != nil {
+				return nil, err
+			}
+		}
+		cfg.Prompts = promptSet
+	}
+
+	return cfg, nil
+}
+
+func (d *Definitions) loggingConfig() (*logging.Config, error) {
+	if d.Logging == nil {
+		return nil, nil
+	}
+
+	config := logging.DefaultConfig()
+	if d.Logging.Level != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(d.Logging.Level)); err != nil {
+			return nil, fmt.Errorf("无效的日志级别 '%s': %w", d.Logging.Level, err)
+		}
+		config.Level = level
+	}
+	if d.Logging.Dir != "" {
+		config.Dir = d.resolvePath(d.Logging.Dir)
+	}
+	if d.Logging.Stdout != nil {
+		config.Stdout = *d.Logging.Stdout
+	}
+	config.JSON = d.Logging.JSON
+	config.Rotation = logging.Rotation{
+		MaxSizeBytes: int64(d.Logging.MaxSizeMB) * 1024 * 1024,
+		MaxBackups:   d.Logging.MaxBackups,
+	}
+	return config, nil
+}
+
+func (d *Definitions) Tracer() (*tracing.Tracer, error) {
+	if d.Tracing == nil {
+		return nil, nil
+	}
+
+	config := *d.Tracing
+	if config.Path != "" {
+		config.Path = d.resolvePath(config.Path)
+	}
+	return config.NewTracer()
+}
+
+func (d *Definitions) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(d.baseDir, path)
+}
+
+func BuildFromDefinitions(defs *Definitions, name string, llmClient *llmclient.LLMClient, baseCtx *tools.Context) (*agent.Agent, error) {
+	config, err := defs.AgentConfig(name, DefaultRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("无法从定义构建 agent: %w", err)
+	}
+	return BuildAgent(config, llmClient, baseCtx)
+}
+
+func toolNameHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() == reflect.String && to == reflect.TypeOf(ToolDefinition{}) {
+		return ToolDefinition{Name: data.(string)}, nil
+	}
+	return data, nil
+}
@@ -0,0 +1,115 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+
+	"hivemind-go/pkg/agent"
+	"hivemind-go/pkg/history"
+	"hivemind-go/pkg/llmclient"
+	"hivemind-go/pkg/prompts"
+)
+
+type HistoryConfig struct {
+	Strategy string `mapstructure:"strategy"`
+
+	KeepLast int `mapstructure:"keep_last"`
+
+	TopK int `mapstructure:"top_k"`
+
+	MinScore float64 `mapstructure:"min_score"`
+
+	Type string `mapstructure:"type"`
+
+	Types []string `mapstructure:"types"`
+
+	PreviewChars int `mapstructure:"preview_chars"`
+
+	Chain []HistoryConfig `mapstructure:"chain"`
+}
+
+const historyStrategies = "none, keep_last, retrieval, drop_resolved, collapse_tool_results, keep_last_of_type, chain"
+
+func (c *HistoryConfig) validate() error {
+	switch c.Strategy {
+	case "", "none", "drop_resolved":
+	case "keep_last", "retrieval", "keep_last_of_type":
+		if c.KeepLast <= 0 {
+			return fmt.Errorf("history 策略 '%s' 需要设置 keep_last", c.Strategy)
+		}
+	case "collapse_tool_results":
+		if c.KeepLast < 0 || c.PreviewChars < 0 {
+			return fmt.Errorf("history 策略 '%s' 的 keep_last 与 preview_chars 不能为负数", c.Strategy)
+		}
+	case "chain":
+		if len(c.Chain) == 0 {
+			return fmt.Errorf("history 策略 'chain' 需要至少一个子策略")
+		}
+		var errs []error
+		for i := range c.Chain {
+			if err := c.Chain[i].validate(); err != nil {
+				errs = append(errs, fmt.Errorf("chain[%d]: %w", i, err))
+			}
+		}
+		return errors.Join(errs...)
+	default:
+		return fmt.Errorf("未知的 history 策略 '%s' (可选: %s)", c.Strategy, historyStrategies)
+	}
+	if c.TopK < 0 || c.MinScore < -1 || c.MinScore > 1 {
+		return fmt.Errorf("history 的 top_k 不能为负数，min_score 必须在 [-1, 1] 之间")
+	}
+	return nil
+}
+
+func (c *HistoryConfig) usesRetrieval() bool {
+	if c.Strategy == "retrieval" {
+		return true
+	}
+	for i := range c.Chain {
+		if c.Chain[i].usesRetrieval() {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *HistoryConfig) build(llmClient *llmclient.LLMClient, promptSet *prompts.Set) (history.Strategy, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c.strategy(llmClient, promptSet), nil
+}
+
+func (c *HistoryConfig) strategy(llmClient *llmclient.LLMClient, promptSet *prompts.Set) history.Strategy {
+	switch c.Strategy {
+	case "keep_last":
+		return history.NewKeepLastN(c.KeepLast)
+	case "retrieval":
+		strategy := history.NewRetrieval(llmClient, c.KeepLast, c.TopK)
+		strategy.MinScore = c.MinScore
+		strategy.Prompts = promptSet
+		return strategy
+	case "drop_resolved":
+		return &history.DropResolved{Types: c.Types}
+	case "collapse_tool_results":
+		return &history.CollapseToolResults{KeepLast: c.KeepLast, PreviewChars: c.PreviewChars, Types: c.Types, Prompts: promptSet}
+	case "keep_last_of_type":
+		return &history.KeepLastOfType{Type: c.Type, N: c.KeepLast}
+	case "chain":
+		chain := make(history.Chain, len(c.Chain))
+		for i := range c.Chain {
+			chain[i] = c.Chain[i].strategy(llmClient, promptSet)
+		}
+		return chain
+	default:
+		return &history.NoOpStrategy{}
+	}
+}
+
+func validateOverflow(overflow string) error {
+	switch agent.OverflowStrategy(overflow) {
+	case "", agent.OverflowTruncate, agent.OverflowSummarize, agent.OverflowFail:
+		return nil
+	}
+	return fmt.Errorf("未知的 overflow 策略 '%s' (可选: truncate, summarize, fail)", overflow)
+}
@@ -0,0 +1,45 @@
+package builder
+
+import (
+	"hivemind-go/pkg/memory"
+	"hivemind-go/pkg/tools"
+)
+
+type MemoryOptions struct {
+	Path string `mapstructure:"path"`
+
+	TopK int `mapstructure:"top_k"`
+
+	MinScore float64 `mapstructure:"min_score"`
+}
+
+const defaultMemoryPath = "memory/memory.json"
+
+func init() {
+	RegisterTool(DefaultRegistry, "Remember", func(env BuildEnv, opts MemoryOptions) (tools.Tool, error) {
+		m, err := openMemory(env, opts)
+		if err != nil {
+			return nil, err
+		}
+		return memory.NewRememberTool(m), nil
+	})
+	RegisterTool(DefaultRegistry, "Recall", func(env BuildEnv, opts MemoryOptions) (tools.Tool, error) {
+		m, err := openMemory(env, opts)
+		if err != nil {
+			return nil, err
+		}
+		return memory.NewRecallTool(m, opts.TopK, opts.MinScore), nil
+	})
+}
+
+func openMemory(env BuildEnv, opts MemoryOptions) (*memory.Memory, error) {
+	path := opts.Path
+	if path == "" {
+		path = defaultMemoryPath
+	}
+	store, err := memory.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &memory.Memory{Store: store, Embedder: env.LLMClient}, nil
+}
@@ -0,0 +1,223 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-viper/mapstructure/v2"
+
+	"hivemind-go/pkg/llmclient"
+	"hivemind-go/pkg/tools"
+)
+
+type Options map[string]interface{}
+
+type NoOptions struct{}
+
+type BuildEnv struct {
+	Ctx *tools.Context
+
+	LLMClient *llmclient.LLMClient
+
+	SubAgent *AgentConfig
+
+	Registry *Registry
+
+	validated bool
+}
+
+type Factory[O any] func(env BuildEnv, opts O) (tools.Tool, error)
+
+type registration struct {
+	name string
+
+	assistant bool
+
+	decode func(opts Options) (interface{}, error)
+
+	build func(env BuildEnv, decoded interface{}) (tools.Tool, error)
+}
+
+type Registry struct {
+	mu sync.RWMutex
+
+	entries map[string]*registration
+}
+
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		entries: make(map[string]*registration),
+	}
+}
+
+func RegisterTool[O any](r *Registry, name string, factory Factory[O]) {
+	r.register(newRegistration(name, false, factory))
+}
+
+func RegisterAssistant[O any](r *Registry, name string, factory Factory[O]) {
+	r.register(newRegistration(name, true, factory))
+}
+
+func newRegistration[O any](name string, assistant bool, factory Factory[O]) *registration {
+	return &registration{
+		name:      name,
+		assistant: assistant,
+		decode: func(opts Options) (interface{}, error) {
+			return decodeOptions[O](opts)
+		},
+		build: func(env BuildEnv, decoded interface{}) (tools.Tool, error) {
+			return factory(env, decoded.(O))
+		},
+	}
+}
+
+func (r *Registry) register(reg *registration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[reg.name] = reg
+}
+
+func (r *Registry) lookup(name string) (*registration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, ok := r.entries[name]
+	return reg, ok
+}
+
+func (r *Registry) Has(name string) bool {
+	_, ok := r.lookup(name)
+	return ok
+}
+
+func (r *Registry) IsAssistant(name string) bool {
+	reg, ok := r.lookup(name)
+	return ok && reg.assistant
+}
+
+func (r *Registry) ToolNames() []string {
+	return r.names(false)
+}
+
+func (r *Registry) AssistantNames() []string {
+	return r.names(true)
+}
+
+func (r *Registry) names(assistant bool) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var names []string
+	for name, reg := range r.entries {
+		if reg.assistant == assistant {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Registry) CheckSpec(spec ToolSpec) error {
+	_, _, err := r.resolve(spec)
+	return err
+}
+
+func (r *Registry) Build(spec ToolSpec, env BuildEnv) (tools.Tool, error) {
+	reg, decoded, err := r.resolve(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	env.SubAgent = spec.SubAgent
+	if env.Registry == nil {
+		env.Registry = r
+	}
+
+	tool, err := reg.build(env, decoded)
+	if err != nil {
+		return nil, fmt.Errorf("创建工具 '%s' 失败: %w", spec.Name, err)
+	}
+	return tool, nil
+}
+
+func (r *Registry) resolve(spec ToolSpec) (*registration, interface{}, error) {
+	reg, ok := r.lookup(spec.Name)
+	if !ok {
+		known := append(r.ToolNames(), r.AssistantNames()...)
+		return nil, nil, fmt.Errorf("未注册的工具 '%s' (已注册: %s)", spec.Name, strings.Join(known, ", "))
+	}
+
+	if reg.assistant && spec.SubAgent == nil {
+		return nil, nil, fmt.Errorf("assistant '%s' 缺少子 agent 配置", spec.Name)
+	}
+	if !reg.assistant && spec.SubAgent != nil {
+		return nil, nil, fmt.Errorf("工具 '%s' 不是 assistant，不能配置子 agent", spec.Name)
+	}
+
+	decoded, err := reg.decode(spec.Options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("工具 '%s' 的选项无效: %w", spec.Name, err)
+	}
+	return reg, decoded, nil
+}
+
+func decodeOptions[O any](opts Options) (O, error) {
+	var decoded O
+
+	optType := reflect.TypeOf(decoded)
+	if optType != nil && optType.Kind() == reflect.Struct {
+		var missing []string
+		for i := 0; i < optType.NumField(); i++ {
+			field := optType.Field(i)
+			if field.Tag.Get("required") != "true" {
+				continue
+			}
+			key := optionKey(field)
+			if !hasOption(opts, key) {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			return decoded, fmt.Errorf("缺少必需选项: %s", strings.Join(missing, ", "))
+		}
+	}
+
+	if len(opts) == 0 {
+		return decoded, nil
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           &decoded,
+		ErrorUnused:      true,
+		WeaklyTypedInput: true,
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+	})
+	if err != nil {
+		return decoded, err
+	}
+	if err := decoder.Decode(map[string]interface{}(opts)); err != nil {
+		return decoded, err
+	}
+	return decoded, nil
+}
+
+func hasOption(opts Options, key string) bool {
+	for name := range opts {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func optionKey(field reflect.StructField) string {
+	tag := field.Tag.Get("mapstructure")
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return field.Name
+}
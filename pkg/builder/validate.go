@@ -0,0 +1,145 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"hivemind-go/pkg/llmclient"
+)
+
+const DefaultMaxDelegationDepth = 5
+
+type validator struct {
+	registry  *Registry
+	llmClient *llmclient.LLMClient
+	maxDepth  int
+
+	visitedDepth map[*AgentConfig]int
+	seen         map[string]bool
+	errs         []error
+}
+
+func (r *Registry) Validate(config *AgentConfig, llmClient *llmclient.LLMClient) error {
+	if config == nil {
+		return fmt.Errorf("agent 配置为空")
+	}
+
+	maxDepth := config.MaxDelegationDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDelegationDepth
+	}
+
+	v := &validator{
+		registry:     r,
+		llmClient:    llmClient,
+		maxDepth:     maxDepth,
+		visitedDepth: make(map[*AgentConfig]int),
+		seen:         make(map[string]bool),
+	}
+	provider := ""
+	if llmClient != nil {
+		provider = llmClient.ProviderName()
+	}
+	v.walk(config, nil, provider)
+
+	return errors.Join(v.errs...)
+}
+
+func Validate(config *AgentConfig, llmClient *llmclient.LLMClient) error {
+	return DefaultRegistry.Validate(config, llmClient)
+}
+
+func (v *validator) walk(config *AgentConfig, path []*AgentConfig, provider string) {
+	depth := len(path)
+	if prev, ok := v.visitedDepth[config]; ok && prev <= depth {
+		return
+	}
+	v.visitedDepth[config] = depth
+
+	path = append(path, config)
+
+	if config.Name == "" {
+		v.report("%s: agent 缺少名称", formatPath(path))
+	}
+
+	if config.Model != "" {
+		provider = config.Model
+	}
+	if v.llmClient != nil {
+		if !v.llmClient.HasProvider(provider) {
+			v.report("%s: 模型配置 '%s' 不存在", formatPath(path), provider)
+		} else if err := v.llmClient.CheckProvider(provider); err != nil {
+			v.report("%s: 模型配置 '%s' 不可用: %v", formatPath(path), provider, err)
+		}
+	}
+
+	if config.History != nil {
+		if err := config.History.validate(); err != nil {
+			v.report("%s: %v", formatPath(path), err)
+		}
+		if config.History.usesRetrieval() && v.llmClient != nil && v.llmClient.HasProvider(provider) {
+			if _, err := v.llmClient.WithProvider(provider).EmbeddingModel(); err != nil {
+				v.report("%s: history 策略 'retrieval' 需要可用的 embedding 模型: %v", formatPath(path), err)
+			}
+		}
+	}
+	if err := validateOverflow(config.Overflow); err != nil {
+		v.report("%s: %v", formatPath(path), err)
+	}
+
+	names := make(map[string]bool, len(config.Tools))
+	for _, spec := range config.Tools {
+		if names[spec.Name] {
+			v.report("%s: 工具 '%s' 重复配置", formatPath(path), spec.Name)
+		}
+		names[spec.Name] = true
+
+		if err := v.registry.CheckSpec(spec); err != nil {
+			v.report("%s: %v", formatPath(path), err)
+		}
+
+		if spec.SubAgent == nil {
+			continue
+		}
+
+		if cycleStart := indexOf(path, spec.SubAgent); cycleStart >= 0 {
+			cycle := append(append([]*AgentConfig{}, path[cycleStart:]...), spec.SubAgent)
+			v.report("检测到循环委托: %s", formatPath(cycle))
+			continue
+		}
+
+		if depth+1 > v.maxDepth {
+			v.report("%s -> %s: 委托深度超过上限 %d", formatPath(path), spec.SubAgent.Name, v.maxDepth)
+			continue
+		}
+
+		v.walk(spec.SubAgent, path, provider)
+	}
+}
+
+func (v *validator) report(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if v.seen[msg] {
+		return
+	}
+	v.seen[msg] = true
+	v.errs = append(v.errs, errors.New(msg))
+}
+
+func indexOf(path []*AgentConfig, config *AgentConfig) int {
+	for i, c := range path {
+		if c == config {
+			return i
+		}
+	}
+	return -1
+}
+
+func formatPath(path []*AgentConfig) string {
+	names := make([]string, len(path))
+	for i, c := range path {
+		names[i] = c.Name
+	}
+	return strings.Join(names, " -> ")
+}
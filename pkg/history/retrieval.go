@@ -0,0 +1,254 @@
+package history
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"hivemind-go/pkg/memory"
+	"hivemind-go/pkg/prompts"
+	"hivemind-go/pkg/types"
+)
+
+type Retrieval struct {
+	Embedder memory.Embedder
+
+	KeepLast int
+
+	TopK int
+
+	MinScore float64
+
+	QueryMessages int
+
+	Prompts *prompts.Set
+
+	mu    sync.Mutex
+	store *memory.Store
+	keys  map[string]string
+}
+
+func NewRetrieval(embedder memory.Embedder, keepLast, topK int) *Retrieval {
+	return &Retrieval{Embedder: embedder, KeepLast: keepLast, TopK: topK}
+}
+
+func (s *Retrieval) Apply(messages []types.Message) []types.Message {
+	pinned, _, recent := s.split(messages)
+	return append(pinned, recent...)
+}
+
+func (s *Retrieval) ApplyContext(ctx context.Context, messages []types.Message) []types.Message {
+	pinned, evicted, recent := s.split(messages)
+	if len(evicted) == 0 {
+		return append(pinned, recent...)
+	}
+
+	logger := slog.Default().With("component", "history", "strategy", "retrieval")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.index(ctx, evicted); err != nil {
+		logger.Warn("failed to index evicted messages", "error", err)
+		return append(pinned, recent...)
+	}
+
+	retrieved, err := s.retrieve(ctx, evicted, recent)
+	if err != nil {
+		logger.Warn("failed to retrieve history", "error", err)
+		return append(pinned, recent...)
+	}
+	if len(retrieved) == 0 {
+		return append(pinned, recent...)
+	}
+
+	promptSet := s.Prompts
+	if promptSet == nil {
+		promptSet = prompts.Default()
+	}
+	data := prompts.HistoryData{}
+	for _, m := range retrieved {
+		data.Entries = append(data.Entries, prompts.HistoryEntry{Role: m.Role, Type: m.Type, Content: m.Content})
+	}
+	content, err := promptSet.Render(prompts.RetrievedHistory, data)
+	if err != nil {
+		logger.Warn("failed to render retrieved history", "error", err)
+		return append(pinned, recent...)
+	}
+
+	result := append(pinned, types.Message{Role: "user", Content: content, Type: "retrieved_history"})
+	return append(result, recent...)
+}
+
+func (s *Retrieval) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = nil
+	s.keys = nil
+}
+
+func (s *Retrieval) split(messages []types.Message) (pinned, evicted, recent []types.Message) {
+	rest := make([]types.Message, 0, len(messages))
+	for _, m := range messages {
+		if m.Type == "system_prompt" {
+			pinned = append(pinned, m)
+		} else {
+			rest = append(rest, m)
+		}
+	}
+
+	keep := s.KeepLast
+	if keep <= 0 || keep >= len(rest) {
+		return pinned, nil, rest
+	}
+
+	cut := len(rest) - keep
+	if rest[cut].Role == "assistant" && cut > 0 {
+		cut--
+	}
+
+	task := -1
+	for i := cut - 1; i >= 0; i-- {
+		if rest[i].Type == "user_input" {
+			task = i
+			break
+		}
+	}
+	for i := cut; i < len(rest) && task >= 0; i++ {
+		if rest[i].Type == "user_input" {
+			task = -1
+		}
+	}
+	if task >= 0 {
+		pinned = append(pinned, rest[task])
+	}
+
+	for i, m := range rest[:cut] {
+		if i != task {
+			evicted = append(evicted, m)
+		}
+	}
+	return pinned, evicted, rest[cut:]
+}
+
+func (s *Retrieval) index(ctx context.Context, evicted []types.Message) error {
+	if s.store == nil {
+		s.store, _ = memory.Open("")
+		s.keys = make(map[string]string)
+	}
+
+	current := make(map[string]bool, len(evicted))
+	var pending []types.Message
+	var pendingKeys []string
+	for i, key := range messageKeys(evicted) {
+		current[key] = true
+		m := evicted[i]
+		if _, indexed := s.keys[key]; indexed || strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+		pending = append(pending, m)
+		pendingKeys = append(pendingKeys, key)
+	}
+	for key, id := range s.keys {
+		if !current[key] {
+			s.store.Delete(id)
+			delete(s.keys, key)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	inputs := make([]string, len(pending))
+	for i, m := range pending {
+		inputs[i] = m.Content
+	}
+	model, err := memory.EmbeddingModel(s.Embedder)
+	if err != nil {
+		return err
+	}
+	vectors, err := s.Embedder.Embed(ctx, inputs)
+	if err != nil {
+		return err
+	}
+
+	for i, m := range pending {
+		entry, err := s.store.Add(model, m.Content, vectors[i], map[string]string{"key": pendingKeys[i]})
+		if err != nil {
+			return err
+		}
+		s.keys[pendingKeys[i]] = entry.ID
+	}
+	return nil
+}
+
+func (s *Retrieval) retrieve(ctx context.Context, evicted, recent []types.Message) ([]types.Message, error) {
+	n := s.QueryMessages
+	if n <= 0 {
+		n = 2
+	}
+	var query []string
+	for i := len(recent) - 1; i >= 0 && len(query) < n; i-- {
+		if strings.TrimSpace(recent[i].Content) != "" {
+			query = append(query, recent[i].Content)
+		}
+	}
+	if len(query) == 0 {
+		return nil, nil
+	}
+
+	model, err := memory.EmbeddingModel(s.Embedder)
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := s.Embedder.Embed(ctx, []string{strings.Join(query, "\n\n")})
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]int, len(evicted))
+	for i, key := range messageKeys(evicted) {
+		positions[key] = i
+	}
+
+	topK := s.TopK
+	if topK <= 0 {
+		topK = 3
+	}
+
+	var selected []int
+	for _, r := range s.store.Search(model, vectors[0], 0, s.MinScore) {
+		pos, ok := positions[r.Metadata["key"]]
+		if !ok {
+			continue
+		}
+		selected = append(selected, pos)
+		if len(selected) >= topK {
+			break
+		}
+	}
+	sort.Ints(selected)
+
+	retrieved := make([]types.Message, len(selected))
+	for i, pos := range selected {
+		retrieved[i] = evicted[pos]
+	}
+	return retrieved, nil
+}
+
+func messageKeys(messages []types.Message) []string {
+	keys := make([]string, len(messages))
+	occurrences := make(map[string]int)
+	for i, m := range messages {
+		sum := sha256.Sum256([]byte(m.Role + "\x00" + m.Type + "\x00" + m.Content))
+		hash := hex.EncodeToString(sum[:8])
+		keys[i] = hash + ":" + strconv.Itoa(occurrences[hash])
+		occurrences[hash]++
+	}
+	return keys
+}
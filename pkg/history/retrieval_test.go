@@ -0,0 +1,135 @@
+package history
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"hivemind-go/pkg/types"
+)
+
+type countingEmbedder struct {
+	inputs int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	e.inputs += len(inputs)
+	vectors := make([][]float32, len(inputs))
+	for i := range inputs {
+		vectors[i] = []float32{1, 0}
+	}
+	return vectors, nil
+}
+
+func reply(content string) types.Message {
+	return types.Message{Role: "assistant", Type: "llm_output", Content: content}
+}
+
+func TestRetrievalSplit(t *testing.T) {
+	history := []types.Message{
+		msg("system_prompt", "sys"),
+		msg("user_input", "task"),
+		reply("out1"),
+		msg("tool_result", "res1"),
+		reply("out2"),
+		msg("tool_result", "res2"),
+	}
+
+	tests := []struct {
+		name     string
+		keepLast int
+		messages []types.Message
+		pinned   []string
+		evicted  []string
+		recent   []string
+	}{
+		{
+			name:     "disabled",
+			messages: history,
+			pinned:   []string{"sys"},
+			recent:   []string{"task", "out1", "res1", "out2", "res2"},
+		},
+		{
+			name:     "keep covers everything",
+			keepLast: 5,
+			messages: history,
+			pinned:   []string{"sys"},
+			recent:   []string{"task", "out1", "res1", "out2", "res2"},
+		},
+		{
+			name:     "pins the task of the kept turns",
+			keepLast: 1,
+			messages: history,
+			pinned:   []string{"sys", "task"},
+			evicted:  []string{"out1", "res1", "out2"},
+			recent:   []string{"res2"},
+		},
+		{
+			name:     "does not start the window on an assistant reply",
+			keepLast: 2,
+			messages: history,
+			pinned:   []string{"sys", "task"},
+			evicted:  []string{"out1"},
+			recent:   []string{"res1", "out2", "res2"},
+		},
+		{
+			name:     "old task is evicted once a new one is kept",
+			keepLast: 2,
+			messages: append(history[:4:4], msg("user_input", "task2"), reply("out3")),
+			pinned:   []string{"sys"},
+			evicted:  []string{"task", "out1", "res1"},
+			recent:   []string{"task2", "out3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Retrieval{KeepLast: tt.keepLast}
+			pinned, evicted, recent := s.split(tt.messages)
+			if got := contents(pinned); !reflect.DeepEqual(got, tt.pinned) {
+				t.Errorf("pinned = %v, want %v", got, tt.pinned)
+			}
+			if got := contents(evicted); len(got)+len(tt.evicted) > 0 && !reflect.DeepEqual(got, tt.evicted) {
+				t.Errorf("evicted = %v, want %v", got, tt.evicted)
+			}
+			if got := contents(recent); !reflect.DeepEqual(got, tt.recent) {
+				t.Errorf("recent = %v, want %v", got, tt.recent)
+			}
+		})
+	}
+}
+
+func TestRetrievalIndex(t *testing.T) {
+	embedder := &countingEmbedder{}
+	s := NewRetrieval(embedder, 1, 3)
+	ctx := context.Background()
+
+	first := []types.Message{msg("user_input", "task"), reply("a"), msg("tool_result", "b"), reply("c"), msg("tool_result", "d")}
+	got := s.ApplyContext(ctx, first)
+	if len(got) != 3 || got[0].Content != "task" || got[1].Type != "retrieved_history" || got[2].Content != "d" {
+		t.Fatalf("ApplyContext = %v, want task, retrieved history, d", contents(got))
+	}
+	if s.store.Len() != 3 || embedder.inputs != 4 {
+		t.Fatalf("after first apply: %d indexed, %d embedded; want 3, 4", s.store.Len(), embedder.inputs)
+	}
+
+	s.ApplyContext(ctx, first)
+	if embedder.inputs != 5 {
+		t.Errorf("already indexed messages were embedded again: %d inputs", embedder.inputs)
+	}
+
+	second := []types.Message{msg("user_input", "task"), msg("tool_result", "b"), reply("c"), reply("e"), msg("tool_result", "f")}
+	s.ApplyContext(ctx, second)
+	if s.store.Len() != 3 || len(s.keys) != 3 || embedder.inputs != 7 {
+		t.Errorf("after history changed: %d indexed, %d embedded; want 3, 7", s.store.Len(), embedder.inputs)
+	}
+
+	s.Reset()
+	if s.store != nil || s.keys != nil {
+		t.Fatal("Reset kept the index")
+	}
+	s.ApplyContext(ctx, first)
+	if s.store.Len() != 3 {
+		t.Errorf("index after Reset = %d, want 3", s.store.Len())
+	}
+}
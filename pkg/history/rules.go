@@ -0,0 +1,185 @@
+package history
+
+import (
+	"context"
+	"slices"
+
+	"hivemind-go/pkg/prompts"
+	"hivemind-go/pkg/types"
+)
+
+type Chain []Strategy
+
+func NewChain(strategies ...Strategy) Chain {
+	return Chain(strategies)
+}
+
+func (c Chain) Apply(messages []types.Message) []types.Message {
+	result := copyMessages(messages)
+	for _, s := range c {
+		result = s.Apply(result)
+	}
+	return result
+}
+
+func (c Chain) ApplyContext(ctx context.Context, messages []types.Message) []types.Message {
+	result := copyMessages(messages)
+	for _, s := range c {
+		result = Apply(ctx, s, result)
+	}
+	return result
+}
+
+func (c Chain) Reset() {
+	for _, s := range c {
+		Reset(s)
+	}
+}
+
+var DefaultResolvedTypes = []string{"parse_error", "system_warning"}
+
+type DropResolved struct {
+	Types []string
+}
+
+func (s *DropResolved) Apply(messages []types.Message) []types.Message {
+	dropTypes := s.Types
+	if len(dropTypes) == 0 {
+		dropTypes = DefaultResolvedTypes
+	}
+
+	lastOutput := -1
+	for i, m := range messages {
+		if m.Type == "llm_output" {
+			lastOutput = i
+		}
+	}
+
+	drop := make([]bool, len(messages))
+	for i, m := range messages {
+		if !slices.Contains(dropTypes, m.Type) || i > lastOutput {
+			continue
+		}
+		drop[i] = true
+		if i > 0 && messages[i-1].Type == "llm_output" && i-1 != lastOutput {
+			drop[i-1] = true
+		}
+	}
+
+	result := make([]types.Message, 0, len(messages))
+	for i, m := range messages {
+		if !drop[i] {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+var DefaultToolResultTypes = []string{"tool_result", "background_tool_result"}
+
+type CollapseToolResults struct {
+	KeepLast int
+
+	PreviewChars int
+
+	Types []string
+
+	Prompts *prompts.Set
+}
+
+func (s *CollapseToolResults) Apply(messages []types.Message) []types.Message {
+	resultTypes := s.Types
+	if len(resultTypes) == 0 {
+		resultTypes = DefaultToolResultTypes
+	}
+	previewChars := s.PreviewChars
+	if previewChars <= 0 {
+		previewChars = 200
+	}
+	promptSet := s.Prompts
+	if promptSet == nil {
+		promptSet = prompts.Default()
+	}
+
+	result := copyMessages(messages)
+	seen := 0
+	for i := len(result) - 1; i >= 0; i-- {
+		if !slices.Contains(resultTypes, result[i].Type) {
+			continue
+		}
+		seen++
+		if seen <= s.KeepLast {
+			continue
+		}
+
+		content := []rune(result[i].Content)
+		if len(content) <= previewChars {
+			continue
+		}
+		collapsed, err := promptSet.Render(prompts.CollapsedResult, prompts.CollapsedData{
+			Preview: string(content[:previewChars]),
+			Omitted: len(content) - previewChars,
+		})
+		if err != nil {
+			continue
+		}
+		result[i].Content = collapsed
+	}
+	return result
+}
+
+var DefaultTurnResultTypes = []string{"tool_result", "tool_error", "background_tool_result", "background_tool_error"}
+
+type KeepLastOfType struct {
+	Type string
+
+	N int
+
+	ResultTypes []string
+}
+
+func (s *KeepLastOfType) Apply(messages []types.Message) []types.Message {
+	msgType := s.Type
+	if msgType == "" {
+		msgType = "llm_output"
+	}
+	resultTypes := s.ResultTypes
+	if len(resultTypes) == 0 {
+		resultTypes = DefaultTurnResultTypes
+	}
+
+	seen := 0
+	keep := make([]bool, len(messages))
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Type != msgType {
+			keep[i] = true
+			continue
+		}
+		seen++
+		keep[i] = s.N <= 0 || seen <= s.N
+	}
+
+	dropping := false
+	for i, m := range messages {
+		switch {
+		case m.Type == msgType:
+			dropping = !keep[i]
+		case dropping && slices.Contains(resultTypes, m.Type):
+			keep[i] = false
+		}
+	}
+
+	result := make([]types.Message, 0, len(messages))
+	for i, m := range messages {
+		if keep[i] {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func copyMessages(messages []types.Message) []types.Message {
+	copied := make([]types.Message, len(messages))
+	copy(copied, messages)
+	return copied
+}
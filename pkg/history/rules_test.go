@@ -0,0 +1,71 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+
+	"hivemind-go/pkg/types"
+)
+
+func msg(msgType, content string) types.Message {
+	return types.Message{Role: "user", Type: msgType, Content: content}
+}
+
+func contents(messages []types.Message) []string {
+	out := make([]string, len(messages))
+	for i, m := range messages {
+		out[i] = m.Content
+	}
+	return out
+}
+
+func TestKeepLastOfType(t *testing.T) {
+	history := []types.Message{
+		msg("system_prompt", "sys"),
+		msg("user_input", "task"),
+		msg("llm_output", "out1"),
+		msg("tool_result", "res1"),
+		msg("llm_output", "out2"),
+		msg("tool_error", "err2"),
+		msg("system_note", "note"),
+		msg("llm_output", "out3"),
+		msg("tool_result", "res3"),
+		msg("background_tool_result", "bg3"),
+		msg("llm_output", "out4"),
+	}
+
+	tests := []struct {
+		name     string
+		strategy KeepLastOfType
+		want     []string
+	}{
+		{
+			name:     "keeps everything when N is zero",
+			strategy: KeepLastOfType{},
+			want:     contents(history),
+		},
+		{
+			name:     "drops old turns with their results",
+			strategy: KeepLastOfType{N: 2},
+			want:     []string{"sys", "task", "note", "out3", "res3", "bg3", "out4"},
+		},
+		{
+			name:     "keeps non-result messages inside dropped turns",
+			strategy: KeepLastOfType{N: 1},
+			want:     []string{"sys", "task", "note", "out4"},
+		},
+		{
+			name:     "custom result types",
+			strategy: KeepLastOfType{N: 1, ResultTypes: []string{"tool_result"}},
+			want:     []string{"sys", "task", "err2", "note", "bg3", "out4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := contents(tt.strategy.Apply(history))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,40 @@
 package history
 
-import "hivemind-go/pkg/types"
+import (
+	"context"
+
+	"hivemind-go/pkg/types"
+)
 
 type Strategy interface {
 	Apply(messages []types.Message) []types.Message
 }
 
+type ContextStrategy interface {
+	Strategy
+
+	ApplyContext(ctx context.Context, messages []types.Message) []types.Message
+}
+
+func Apply(ctx context.Context, strategy Strategy, messages []types.Message) []types.Message {
+	if cs, ok := strategy.(ContextStrategy); ok {
+		return cs.ApplyContext(ctx, messages)
+	}
+	return strategy.Apply(messages)
+}
+
+type ResettableStrategy interface {
+	Strategy
+
+	Reset()
+}
+
+func Reset(strategy Strategy) {
+	if rs, ok := strategy.(ResettableStrategy); ok {
+		rs.Reset()
+	}
+}
+
 type NoOpStrategy struct{}
 
 func (s *NoOpStrategy) Apply(messages []types.Message) []types.Message {
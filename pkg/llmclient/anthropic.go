@@ -0,0 +1,204 @@
+package llmclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	anthropicDefaultBaseURL = "https://api.anthropic.com"
+	anthropicVersion        = "2023-06-01"
+	anthropicMaxTokens      = 4096
+)
+
+type anthropicProvider struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+func newAnthropicProvider(conf ProviderConfig, httpClient *http.Client) *anthropicProvider {
+	baseURL := conf.BaseURL
+	if baseURL == "" {
+		baseURL = anthropicDefaultBaseURL
+	}
+	return &anthropicProvider{
+		apiKey:     conf.APIKey,
+		baseURL:    strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1"),
+		httpClient: httpClient,
+	}
+}
+
+type anthropicBlock struct {
+	Type string `json:"type"`
+
+	Text string `json:"text,omitempty"`
+
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float64            `json:"temperature"`
+	Tools       []ToolSpec         `json:"tools,omitempty"`
+}
+
+type anthropicResponse struct {
+	ID         string           `json:"id"`
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      struct {
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *anthropicProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	header := http.Header{}
+	header.Set("x-api-key", p.apiKey)
+	header.Set("anthropic-version", anthropicVersion)
+
+	status, data, err := postJSON(ctx, p.httpClient, p.baseURL+"/v1/messages", header, p.buildRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		apiErr := &APIError{Provider: ProviderAnthropic, StatusCode: status, Type: "error"}
+		var errBody anthropicError
+		if json.Unmarshal(data, &errBody) == nil && errBody.Error.Message != "" {
+			apiErr.Type = errBody.Error.Type
+			apiErr.Message = errBody.Error.Message
+		} else {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return nil, apiErr
+	}
+
+	var resp anthropicResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode anthropic response: %w", err)
+	}
+	return resp.toResponse(), nil
+}
+
+func (p *anthropicProvider) buildRequest(req Request) anthropicRequest {
+	out := anthropicRequest{
+		Model:       req.Model,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+	}
+	if out.MaxTokens <= 0 {
+		out.MaxTokens = anthropicMaxTokens
+	}
+	for _, tool := range req.Tools {
+		if tool.InputSchema == nil {
+			tool.InputSchema = map[string]any{"type": "object"}
+		}
+		out.Tools = append(out.Tools, tool)
+	}
+
+	var system []string
+	for _, msg := range req.Messages {
+		var role string
+		var blocks []anthropicBlock
+
+		switch msg.Role {
+		case "system":
+			system = append(system, msg.Content)
+			continue
+		case "assistant":
+			role = "assistant"
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				input := json.RawMessage(call.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+			}
+		case "tool":
+			role = "user"
+			blocks = append(blocks, anthropicBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content})
+		default:
+			role = "user"
+			blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Content})
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
+		if n := len(out.Messages); n > 0 && out.Messages[n-1].Role == role {
+			out.Messages[n-1].Content = append(out.Messages[n-1].Content, blocks...)
+			continue
+		}
+		out.Messages = append(out.Messages, anthropicMessage{Role: role, Content: blocks})
+	}
+	out.System = strings.Join(system, "\n\n")
+	return out
+}
+
+func (r *anthropicResponse) toResponse() *Response {
+	var text []string
+	var calls []ToolCall
+	for _, block := range r.Content {
+		switch block.Type {
+		case "text":
+			text = append(text, block.Text)
+		case "tool_use":
+			calls = append(calls, ToolCall{ID: block.ID, Name: block.Name, Arguments: string(block.Input)})
+		}
+	}
+
+	prompt := r.Usage.InputTokens + r.Usage.CacheCreationInputTokens + r.Usage.CacheReadInputTokens
+	return &Response{
+		Content:      strings.Join(text, ""),
+		ToolCalls:    calls,
+		FinishReason: anthropicFinishReason(r.StopReason),
+		Usage: Usage{
+			PromptTokens:     prompt,
+			CompletionTokens: r.Usage.OutputTokens,
+			TotalTokens:      prompt + r.Usage.OutputTokens,
+		},
+	}
+}
+
+func anthropicFinishReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence", "pause_turn":
+		return FinishStop
+	case "max_tokens":
+		return FinishLength
+	case "tool_use":
+		return FinishToolCalls
+	default:
+		return stopReason
+	}
+}
@@ -0,0 +1,263 @@
+package llmclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type anthropicStub struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	requests []anthropicRequest
+	replies  []anthropicStubReply
+}
+
+type anthropicStubReply struct {
+	status     int
+	body       string
+	retryAfter string
+}
+
+func (s *anthropicStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/messages" {
+		s.t.Errorf("unexpected path %s", r.URL.Path)
+	}
+	if got := r.Header.Get("x-api-key"); got != "test-key" {
+		s.t.Errorf("x-api-key = %q, want %q", got, "test-key")
+	}
+	if got := r.Header.Get("anthropic-version"); got != anthropicVersion {
+		s.t.Errorf("anthropic-version = %q, want %q", got, anthropicVersion)
+	}
+
+	data, _ := io.ReadAll(r.Body)
+	var req anthropicRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		s.t.Errorf("invalid request body: %v", err)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	reply := s.replies[0]
+	if len(s.replies) > 1 {
+		s.replies = s.replies[1:]
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if reply.retryAfter != "" {
+		w.Header().Set("Retry-After", reply.retryAfter)
+	}
+	w.WriteHeader(reply.status)
+	io.WriteString(w, reply.body)
+}
+
+func newAnthropicTestClient(t *testing.T, replies ...anthropicStubReply) (*LLMClient, *anthropicStub) {
+	stub := &anthropicStub{t: t, replies: replies}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	return NewLLMClient(anthropicTestConfig(srv.URL)), stub
+}
+
+func anthropicTestConfig(baseURL string) *AppConfig {
+	config := &AppConfig{}
+	config.Common.ActiveModel = "claude"
+	config.Providers = map[string]ProviderConfig{
+		"claude": {
+			Name:    "claude",
+			Type:    ProviderAnthropic,
+			Model:   "claude-test",
+			APIKey:  "test-key",
+			BaseURL: baseURL,
+			Retry: &RetryPolicy{
+				MaxAttempts: 3,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    5 * time.Millisecond,
+			},
+		},
+	}
+	return config
+}
+
+func TestAnthropicRequestShape(t *testing.T) {
+	client, stub := newAnthropicTestClient(t, anthropicStubReply{status: http.StatusOK, body: `{
+		"id": "msg_1",
+		"content": [{"type": "text", "text": "done"}],
+		"stop_reason": "end_turn",
+		"usage": {"input_tokens": 10, "output_tokens": 3, "cache_read_input_tokens": 5}
+	}`})
+
+	ctx := WithTools(context.Background(),
+		ToolSpec{Name: "Search", Description: "search the web"},
+		ToolSpec{Name: "Read", InputSchema: map[string]any{"type": "object", "properties": map[string]any{"path": map[string]any{"type": "string"}}}},
+	)
+	resp, err := client.Invoke(ctx, []Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "system", Content: "answer in English"},
+		{Role: "user", Content: "first"},
+		{Role: "user", Content: "second"},
+		{Role: "assistant", Content: "looking", ToolCalls: []ToolCall{{ID: "call_1", Name: "Search", Arguments: `{"q":"go"}`}}},
+		{Role: "tool", ToolCallID: "call_1", Content: "result"},
+		{Role: "user", Content: "continue"},
+	})
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+
+	if len(stub.requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(stub.requests))
+	}
+	req := stub.requests[0]
+
+	if req.System != "be brief\n\nanswer in English" {
+		t.Errorf("system = %q", req.System)
+	}
+	if req.MaxTokens != anthropicMaxTokens {
+		t.Errorf("max_tokens = %d, want %d", req.MaxTokens, anthropicMaxTokens)
+	}
+
+	want := []anthropicMessage{
+		{Role: "user", Content: []anthropicBlock{{Type: "text", Text: "first"}, {Type: "text", Text: "second"}}},
+		{Role: "assistant", Content: []anthropicBlock{
+			{Type: "text", Text: "looking"},
+			{Type: "tool_use", ID: "call_1", Name: "Search", Input: json.RawMessage(`{"q":"go"}`)},
+		}},
+		{Role: "user", Content: []anthropicBlock{
+			{Type: "tool_result", ToolUseID: "call_1", Content: "result"},
+			{Type: "text", Text: "continue"},
+		}},
+	}
+	if !reflect.DeepEqual(req.Messages, want) {
+		got, _ := json.Marshal(req.Messages)
+		t.Errorf("messages = %s", got)
+	}
+
+	if len(req.Tools) != 2 {
+		t.Fatalf("got %d tools, want 2", len(req.Tools))
+	}
+	if !reflect.DeepEqual(req.Tools[0].InputSchema, map[string]any{"type": "object"}) {
+		t.Errorf("default input_schema = %v", req.Tools[0].InputSchema)
+	}
+	if _, ok := req.Tools[1].InputSchema["properties"]; !ok {
+		t.Errorf("explicit input_schema was replaced: %v", req.Tools[1].InputSchema)
+	}
+
+	if resp.Content != "done" || resp.FinishReason != FinishStop {
+		t.Errorf("response = %q / %q", resp.Content, resp.FinishReason)
+	}
+	if resp.Usage != (Usage{PromptTokens: 15, CompletionTokens: 3, TotalTokens: 18}) {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestAnthropicToolUseResponse(t *testing.T) {
+	client, _ := newAnthropicTestClient(t, anthropicStubReply{status: http.StatusOK, body: `{
+		"content": [
+			{"type": "text", "text": "let me check"},
+			{"type": "tool_use", "id": "toolu_1", "name": "Search", "input": {"q": "weather"}}
+		],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 1, "output_tokens": 1}
+	}`})
+
+	resp, err := client.Invoke(context.Background(), []Message{{Role: "user", Content: "weather?"}})
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if resp.FinishReason != FinishToolCalls {
+		t.Errorf("finish reason = %q, want %q", resp.FinishReason, FinishToolCalls)
+	}
+	want := []ToolCall{{ID: "toolu_1", Name: "Search", Arguments: `{"q": "weather"}`}}
+	if !reflect.DeepEqual(resp.ToolCalls, want) {
+		t.Errorf("tool calls = %+v", resp.ToolCalls)
+	}
+}
+
+func TestAnthropicFinishReason(t *testing.T) {
+	cases := map[string]string{
+		"end_turn":      FinishStop,
+		"stop_sequence": FinishStop,
+		"pause_turn":    FinishStop,
+		"max_tokens":    FinishLength,
+		"tool_use":      FinishToolCalls,
+		"refusal":       "refusal",
+	}
+	for stopReason, want := range cases {
+		if got := anthropicFinishReason(stopReason); got != want {
+			t.Errorf("anthropicFinishReason(%q) = %q, want %q", stopReason, got, want)
+		}
+	}
+}
+
+func TestAnthropicErrors(t *testing.T) {
+	overloaded := anthropicStubReply{status: 529, body: `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`}
+	invalid := anthropicStubReply{status: http.StatusBadRequest, body: `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long"}}`}
+
+	t.Run("retries overloaded", func(t *testing.T) {
+		client, stub := newAnthropicTestClient(t, overloaded, overloaded, overloaded)
+		_, err := client.Invoke(context.Background(), []Message{{Role: "user", Content: "hi"}})
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("error %v is not an *APIError", err)
+		}
+		if apiErr.StatusCode != 529 || apiErr.Type != "overloaded_error" || apiErr.Message != "Overloaded" {
+			t.Errorf("APIError = %+v", apiErr)
+		}
+		if len(stub.requests) != 3 {
+			t.Errorf("got %d attempts, want 3", len(stub.requests))
+		}
+	})
+
+	t.Run("fails fast on invalid request", func(t *testing.T) {
+		client, stub := newAnthropicTestClient(t, invalid)
+		_, err := client.Invoke(context.Background(), []Message{{Role: "user", Content: "hi"}})
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("error %v is not an *APIError", err)
+		}
+		if apiErr.StatusCode != http.StatusBadRequest || apiErr.Type != "invalid_request_error" {
+			t.Errorf("APIError = %+v", apiErr)
+		}
+		if len(stub.requests) != 1 {
+			t.Errorf("got %d attempts, want 1", len(stub.requests))
+		}
+	})
+
+	t.Run("gives up when retry-after exceeds max delay", func(t *testing.T) {
+		limited := anthropicStubReply{status: http.StatusTooManyRequests, retryAfter: "60", body: `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`}
+		client, stub := newAnthropicTestClient(t, limited)
+		start := time.Now()
+		_, err := client.Invoke(context.Background(), []Message{{Role: "user", Content: "hi"}})
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("error = %v, want a 429 *APIError", err)
+		}
+		if len(stub.requests) != 1 || time.Since(start) > time.Second {
+			t.Errorf("got %d attempts in %s, want 1 without waiting", len(stub.requests), time.Since(start))
+		}
+	})
+
+	t.Run("recovers after retry", func(t *testing.T) {
+		ok := anthropicStubReply{status: http.StatusOK, body: `{"content":[{"type":"text","text":"hello"}],"stop_reason":"end_turn"}`}
+		client, stub := newAnthropicTestClient(t, overloaded, ok)
+		resp, err := client.Invoke(context.Background(), []Message{{Role: "user", Content: "hi"}})
+		if err != nil {
+			t.Fatalf("Invoke: %v", err)
+		}
+		if resp.Content != "hello" || len(stub.requests) != 2 {
+			t.Errorf("content = %q after %d attempts", resp.Content, len(stub.requests))
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package llmclient
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type Cache interface {
+	Get(key string) (*Response, bool)
+
+	Set(key string, resp *Response)
+}
+
+type CacheConfig struct {
+	Backend string `mapstructure:"backend"`
+
+	MaxEntries int `mapstructure:"max_entries"`
+
+	Dir string `mapstructure:"dir"`
+
+	TTL time.Duration `mapstructure:"ttl"`
+
+	AnyTemperature bool `mapstructure:"any_temperature"`
+}
+
+func (c CacheConfig) validate() error {
+	switch c.Backend {
+	case "", "memory", "disk":
+	default:
+		return fmt.Errorf("cache.backend %q is not supported (memory, disk)", c.Backend)
+	}
+	if c.MaxEntries < 0 {
+		return fmt.Errorf("cache.max_entries must not be negative")
+	}
+	if c.TTL < 0 {
+		return fmt.Errorf("cache.ttl must not be negative")
+	}
+	return nil
+}
+
+func (c CacheConfig) NewCache() Cache {
+	if c.Backend == "disk" {
+		dir := c.Dir
+		if dir == "" {
+			dir = ".llm_cache"
+		}
+		return NewDiskCache(dir, c.TTL)
+	}
+	return NewMemoryCache(c.MaxEntries, c.TTL)
+}
+
+type cacheBypassKey struct{}
+
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+func cacheKey(provider string, req Request) string {
+	data, _ := json.Marshal(struct {
+		Provider    string          `json:"provider"`
+		Model       string          `json:"model"`
+		Temperature float64         `json:"temperature"`
+		MaxTokens   int             `json:"max_tokens,omitempty"`
+		Messages    []Message       `json:"messages"`
+		Tools       []ToolSpec      `json:"tools,omitempty"`
+		Format      *ResponseFormat `json:"format,omitempty"`
+	}{provider, req.Model, req.Temperature, req.MaxTokens, req.Messages, req.Tools, req.Format})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type memoryEntry struct {
+	key     string
+	resp    Response
+	expires time.Time
+}
+
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+func NewMemoryCache(maxEntries int, ttl time.Duration) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (m *MemoryCache) Get(key string) (*Response, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		m.order.Remove(elem)
+		delete(m.entries, key)
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+	resp := entry.resp
+	return &resp, true
+}
+
+func (m *MemoryCache) Set(key string, resp *Response) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := &memoryEntry{key: key, resp: *resp}
+	if m.ttl > 0 {
+		entry.expires = time.Now().Add(m.ttl)
+	}
+
+	if elem, ok := m.entries[key]; ok {
+		elem.Value = entry
+		m.order.MoveToFront(elem)
+		return
+	}
+	m.entries[key] = m.order.PushFront(entry)
+
+	for m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+type diskEntry struct {
+	StoredAt time.Time `json:"stored_at"`
+	Response Response  `json:"response"`
+}
+
+type DiskCache struct {
+	dir string
+	ttl time.Duration
+}
+
+func NewDiskCache(dir string, ttl time.Duration) *DiskCache {
+	return &DiskCache{dir: dir, ttl: ttl}
+}
+
+func (d *DiskCache) path(key string) string {
+	return filepath.Join(d.dir, key[:2], key+".json")
+}
+
+func (d *DiskCache) Get(key string) (*Response, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Default().Warn("failed to read LLM cache entry", "component", "llmclient", "key", key, "error", err)
+		}
+		return nil, false
+	}
+
+	var entry diskEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		os.Remove(d.path(key))
+		return nil, false
+	}
+	if d.ttl > 0 && time.Since(entry.StoredAt) > d.ttl {
+		os.Remove(d.path(key))
+		return nil, false
+	}
+	return &entry.Response, true
+}
+
+func (d *DiskCache) Set(key string, resp *Response) {
+	if err := d.write(key, resp); err != nil {
+		slog.Default().Warn("failed to write LLM cache entry", "component", "llmclient", "key", key, "error", err)
+	}
+}
+
+func (d *DiskCache) write(key string, resp *Response) error {
+	path := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(diskEntry{StoredAt: time.Now(), Response: *resp})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,181 @@
+package llmclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheEviction(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		ops     []string
+		present []string
+		absent  []string
+	}{
+		{name: "evicts least recently set", max: 2, ops: []string{"set a", "set b", "set c"}, present: []string{"b", "c"}, absent: []string{"a"}},
+		{name: "get refreshes recency", max: 2, ops: []string{"set a", "set b", "get a", "set c"}, present: []string{"a", "c"}, absent: []string{"b"}},
+		{name: "overwrite does not grow", max: 2, ops: []string{"set a", "set b", "set a", "set a"}, present: []string{"a", "b"}},
+		{name: "overwrite refreshes recency", max: 2, ops: []string{"set a", "set b", "set a", "set c"}, present: []string{"a", "c"}, absent: []string{"b"}},
+		{name: "default capacity", max: 0, ops: []string{"set a", "set b", "set c"}, present: []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewMemoryCache(tt.max, 0)
+			for _, op := range tt.ops {
+				key := op[4:]
+				if op[:3] == "set" {
+					c.Set(key, &Response{Content: key})
+				} else {
+					c.Get(key)
+				}
+			}
+			for _, key := range tt.present {
+				if resp, ok := c.Get(key); !ok || resp.Content != key {
+					t.Errorf("Get(%q) = %v, %v; want cached", key, resp, ok)
+				}
+			}
+			for _, key := range tt.absent {
+				if _, ok := c.Get(key); ok {
+					t.Errorf("Get(%q) hit, want evicted", key)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	c := NewMemoryCache(10, time.Hour)
+	c.Set("fresh", &Response{Content: "fresh"})
+	c.Set("stale", &Response{Content: "stale"})
+	c.entries["stale"].Value.(*memoryEntry).expires = time.Now().Add(-time.Second)
+
+	if _, ok := c.Get("fresh"); !ok {
+		t.Error("fresh entry missing")
+	}
+	if _, ok := c.Get("stale"); ok {
+		t.Error("expired entry returned")
+	}
+	if _, ok := c.entries["stale"]; ok || c.order.Len() != 1 {
+		t.Errorf("expired entry not removed: %d entries", c.order.Len())
+	}
+
+	resp, _ := c.Get("fresh")
+	resp.Content = "mutated"
+	if again, _ := c.Get("fresh"); again.Content != "fresh" {
+		t.Error("cached response shares memory with the caller")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	const key = "abcdef0123456789"
+
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		stored time.Time
+		raw    string
+		want   bool
+	}{
+		{name: "fresh entry", ttl: time.Hour, stored: time.Now(), want: true},
+		{name: "no ttl never expires", stored: time.Now().Add(-24 * 365 * time.Hour), want: true},
+		{name: "expired entry", ttl: time.Hour, stored: time.Now().Add(-2 * time.Hour), want: false},
+		{name: "corrupt entry", raw: "{not json", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewDiskCache(t.TempDir(), tt.ttl)
+			path := c.path(key)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			data := []byte(tt.raw)
+			if tt.raw == "" {
+				data, _ = json.Marshal(diskEntry{StoredAt: tt.stored, Response: Response{Content: "cached"}})
+			}
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			resp, ok := c.Get(key)
+			if ok != tt.want {
+				t.Fatalf("Get hit = %v, want %v", ok, tt.want)
+			}
+			if ok && resp.Content != "cached" {
+				t.Errorf("Content = %q", resp.Content)
+			}
+			if _, err := os.Stat(path); !tt.want && !os.IsNotExist(err) {
+				t.Errorf("invalid entry was not removed: %v", err)
+			}
+		})
+	}
+
+	c := NewDiskCache(t.TempDir(), 0)
+	if _, ok := c.Get(key); ok {
+		t.Error("missing entry returned a hit")
+	}
+	c.Set(key, &Response{Content: "round trip"})
+	if resp, ok := c.Get(key); !ok || resp.Content != "round trip" {
+		t.Errorf("Get after Set = %v, %v", resp, ok)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	base := Request{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}
+	key := cacheKey("p", base)
+	if key != cacheKey("p", base) {
+		t.Fatal("cache key is not stable")
+	}
+
+	variants := map[string]func(r *Request) string{
+		"provider":    func(r *Request) string { return "other" },
+		"model":       func(r *Request) string { r.Model = "m2"; return "p" },
+		"temperature": func(r *Request) string { r.Temperature = 0.5; return "p" },
+		"messages":    func(r *Request) string { r.Messages = []Message{{Role: "user", Content: "bye"}}; return "p" },
+		"tools":       func(r *Request) string { r.Tools = []ToolSpec{{Name: "Search"}}; return "p" },
+	}
+	for name, change := range variants {
+		req := base
+		provider := change(&req)
+		if cacheKey(provider, req) == key {
+			t.Errorf("changing %s did not change the cache key", name)
+		}
+	}
+}
+
+func TestInvokeUsesCache(t *testing.T) {
+	ok := anthropicStubReply{status: http.StatusOK, body: `{"content":[{"type":"text","text":"hello"}],"stop_reason":"end_turn"}`}
+	stub := &anthropicStub{t: t, replies: []anthropicStubReply{ok}}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	config := anthropicTestConfig(srv.URL)
+	config.Common.Cache = &CacheConfig{Backend: "memory"}
+	client := NewLLMClient(config)
+	msgs := []Message{{Role: "user", Content: "hi"}}
+
+	first, err := client.Invoke(context.Background(), msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.Invoke(context.Background(), msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Cached || !second.Cached || second.Content != "hello" || len(stub.requests) != 1 {
+		t.Fatalf("cached = %v/%v after %d requests", first.Cached, second.Cached, len(stub.requests))
+	}
+
+	if _, err := client.Invoke(WithCacheBypass(context.Background()), msgs); err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.requests) != 2 {
+		t.Errorf("bypass did not reach the provider: %d requests", len(stub.requests))
+	}
+}
@@ -3,104 +3,286 @@ package llmclient
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"reflect"
+	"sync/atomic"
 	"time"
-
-	"github.com/sashabaranov/go-openai"
 )
 
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
 }
 
 type Response struct {
-	Content string
-	Usage   openai.Usage
+	Content      string
+	ToolCalls    []ToolCall
+	FinishReason string
+	Usage        Usage
+	Cached       bool
 }
 
 type LLMClient struct {
-	config  *AppConfig
-	clients map[string]*openai.Client
+	state    *atomic.Pointer[clientState]
+	limiters *limiterSet
+	provider string
 }
 
-func NewLLMClient(config *AppConfig) *LLMClient {
-	clients := make(map[string]*openai.Client)
+type clientState struct {
+	config    *AppConfig
+	providers map[string]Provider
+	cache     Cache
+}
 
-	for name, providerConf := range config.Providers {
+func NewLLMClient(config *AppConfig) *LLMClient {
+	state := &atomic.Pointer[clientState]{}
+	state.Store(newClientState(config, nil))
+	return &LLMClient{state: state, limiters: newLimiterSet()}
+}
 
-		clientConfig := openai.DefaultConfig(providerConf.APIKey)
+func newClientState(config *AppConfig, previous *clientState) *clientState {
+	providers := make(map[string]Provider)
+	httpClient := &http.Client{
+		Transport: &retryAfterTransport{base: http.DefaultTransport},
+	}
 
-		if providerConf.BaseURL != "" {
-			clientConfig.BaseURL = providerConf.BaseURL
+	for name, providerConf := range config.Providers {
+		provider, err := newProvider(providerConf, httpClient)
+		if err != nil {
+			slog.Default().Error("failed to initialize LLM provider", "component", "llmclient", "provider", name, "error", err)
+			continue
 		}
+		providers[name] = provider
+	}
 
-		if providerConf.OrgID != "" {
-			clientConfig.OrgID = providerConf.OrgID
+	var cache Cache
+	if conf := config.Common.Cache; conf != nil {
+		if previous != nil && previous.cache != nil && reflect.DeepEqual(previous.config.Common.Cache, conf) {
+			cache = previous.cache
+		} else {
+			cache = conf.NewCache()
 		}
+	}
+
+	return &clientState{
+		config:    config,
+		providers: providers,
+		cache:     cache,
+	}
+}
 
-		clients[name] = openai.NewClientWithConfig(clientConfig)
+func (c *LLMClient) Reload(config *AppConfig) *AppConfig {
+	for {
+		previous := c.state.Load()
+		if c.state.CompareAndSwap(previous, newClientState(config, previous)) {
+			return previous.config
+		}
 	}
-	return &LLMClient{
-		config:  config,
-		clients: clients,
+}
+
+func (c *LLMClient) Config() *AppConfig {
+	return c.state.Load().config
+}
+
+func (c *LLMClient) WithProvider(name string) *LLMClient {
+	scoped := *c
+	scoped.provider = name
+	return &scoped
+}
+
+func (c *LLMClient) ProviderName() string {
+	return c.providerName(c.state.Load())
+}
+
+func (c *LLMClient) providerName(state *clientState) string {
+	if c.provider != "" {
+		return c.provider
 	}
+	return state.config.Common.ActiveModel
+}
+
+func (c *LLMClient) HasProvider(name string) bool {
+	_, ok := c.state.Load().config.Providers[name]
+	return ok
 }
 
-func (c *LLMClient) Invoke(ctx context.Context, messages []Message, maxRetries int) (*Response, error) {
+func (c *LLMClient) CheckProvider(name string) error {
+	return c.state.Load().config.CheckProvider(name)
+}
+
+func (c *LLMClient) Invoke(ctx context.Context, messages []Message) (*Response, error) {
 
-	providerName := c.config.Common.ActiveModel
-	providerConf, ok := c.config.Providers[providerName]
+	state := c.state.Load()
+	providerName := c.providerName(state)
+	providerConf, ok := state.config.Providers[providerName]
 	if !ok {
 		return nil, fmt.Errorf("active LLM provider '%s' not found in configuration", providerName)
 	}
-	client, ok := c.clients[providerName]
+	provider, ok := state.providers[providerName]
 	if !ok {
 		return nil, fmt.Errorf("client for provider '%s' not initialized", providerName)
 	}
 
-	apiMessages := make([]openai.ChatCompletionMessage, len(messages))
-	for i, msg := range messages {
-		apiMessages[i] = openai.ChatCompletionMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
+	policy := providerConf.RetryPolicy(state.config.Common.Retry)
+	req := Request{
+		Model:       providerConf.Model,
+		Messages:    messages,
+		Temperature: providerConf.Temperature,
+		MaxTokens:   providerConf.MaxTokens,
+		Tools:       toolsFromContext(ctx),
+		Format:      responseFormatFromContext(ctx),
+	}
+
+	var key string
+	if state.cache != nil && !cacheBypassed(ctx) &&
+		(providerConf.Temperature == 0 || state.config.Common.Cache.AnyTemperature) {
+		key = cacheKey(providerName, req)
+		if cached, ok := state.cache.Get(key); ok {
+			observeCache(providerName, providerConf.Model, true)
+			cached.Cached = true
+			return cached, nil
+		}
+		observeCache(providerName, providerConf.Model, false)
+	}
+
+	var result *Response
+	err := c.withRetries(ctx, providerName, providerConf, providerConf.Model, policy, EstimateTokens(messages), func(ctx context.Context) (Usage, error) {
+		resp, err := provider.Complete(ctx, req)
+		if err != nil {
+			return Usage{}, err
+		}
+		result = resp
+		return resp.Usage, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if key != "" {
+		state.cache.Set(key, result)
+	}
+	return result, nil
+}
+
+func (c *LLMClient) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	state := c.state.Load()
+	providerName, providerConf, embedder, err := c.embeddingProvider(state)
+	if err != nil {
+		return nil, err
+	}
+
+	req := EmbeddingRequest{Model: providerConf.EmbeddingModel, Input: inputs}
+	estimated := 0
+	for _, input := range inputs {
+		estimated += EstimateTokens([]Message{{Content: input}})
+	}
+
+	var vectors [][]float32
+	err = c.withRetries(ctx, providerName, providerConf, req.Model, providerConf.RetryPolicy(state.config.Common.Retry), estimated, func(ctx context.Context) (Usage, error) {
+		resp, err := embedder.Embed(ctx, req)
+		if err != nil {
+			return Usage{}, err
+		}
+		if len(resp.Vectors) != len(inputs) {
+			return Usage{}, fmt.Errorf("embedding response contains %d vectors for %d inputs", len(resp.Vectors), len(inputs))
 		}
+		vectors = resp.Vectors
+		return resp.Usage, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+func (c *LLMClient) EmbeddingModel() (string, error) {
+	_, providerConf, _, err := c.embeddingProvider(c.state.Load())
+	if err != nil {
+		return "", err
+	}
+	return providerConf.EmbeddingModel, nil
+}
+
+func (c *LLMClient) embeddingProvider(state *clientState) (string, ProviderConfig, Embedder, error) {
+	providerName := state.config.Common.ActiveEmbeddingModel
+	if providerName == "" {
+		providerName = c.providerName(state)
+	}
+	providerConf, ok := state.config.Providers[providerName]
+	if !ok {
+		return "", ProviderConfig{}, nil, fmt.Errorf("embedding provider '%s' not found in configuration", providerName)
 	}
+	if providerConf.EmbeddingModel == "" {
+		return "", ProviderConfig{}, nil, fmt.Errorf("provider '%s' has no embedding_model configured (set embedding_model or [common].active_embedding_model)", providerName)
+	}
+	embedder, ok := state.providers[providerName].(Embedder)
+	if !ok {
+		return "", ProviderConfig{}, nil, fmt.Errorf("provider '%s' does not support embeddings", providerName)
+	}
+	return providerName, providerConf, embedder, nil
+}
+
+func (c *LLMClient) withRetries(ctx context.Context, providerName string, providerConf ProviderConfig, model string, policy RetryPolicy, estimatedTokens int, call func(ctx context.Context) (Usage, error)) error {
+	logger := slog.Default().With("component", "llmclient", "provider", providerName, "model", model)
+	limiter := c.limiters.get(providerName, providerConf.RateLimit)
 
-	var resp openai.ChatCompletionResponse
 	var err error
+	for attempt := 1; ; attempt++ {
+		var retryAfter time.Duration
+		attemptCtx := context.WithValue(ctx, retryAfterKey{}, &retryAfter)
 
-	for i := 0; i < maxRetries; i++ {
-		req := openai.ChatCompletionRequest{
-			Model:       providerConf.Model,
-			Messages:    apiMessages,
-			Temperature: float32(providerConf.Temperature),
+		release := func(int) {}
+		if limiter != nil {
+			release, err = limiter.acquire(ctx, estimatedTokens)
+			if err != nil {
+				return err
+			}
 		}
 
-		resp, err = client.CreateChatCompletion(ctx, req)
+		attemptStart := time.Now()
+		var usage Usage
+		usage, err = call(attemptCtx)
+		release(usage.TotalTokens)
+		observeRequest(providerName, model, time.Since(attemptStart), err)
 
 		if err == nil {
-			return &Response{
-				Content: resp.Choices[0].Message.Content,
-				Usage:   resp.Usage,
-			}, nil
+			observeUsage(providerName, model, usage)
+			return nil
 		}
 
 		if ctx.Err() != nil {
-			return nil, ctx.Err()
+			return ctx.Err()
 		}
-
-		if apiErr, ok := err.(*openai.APIError); ok {
-			if apiErr.HTTPStatusCode >= http.StatusBadRequest && apiErr.HTTPStatusCode < http.StatusInternalServerError {
-				return nil, fmt.Errorf("unrecoverable API error: %w", err)
-			}
+		if !policy.Retryable(err) {
+			return fmt.Errorf("unrecoverable API error: %w", err)
+		}
+		if attempt >= policy.MaxAttempts {
+			break
+		}
+		if retryAfter > policy.MaxDelay {
+			return fmt.Errorf("server asked to retry after %s, longer than max_delay %s: %w", retryAfter, policy.MaxDelay, err)
 		}
 
-		sleepDuration := time.Second * time.Duration(2<<i)
-		fmt.Printf("LLM request failed (attempt %d/%d): %v. Retrying in %v...\n", i+1, maxRetries, err, sleepDuration)
-
-		time.Sleep(sleepDuration)
+		delay := policy.Delay(attempt, retryAfter)
+		logger.Warn("LLM request failed, retrying",
+			"attempt", attempt,
+			"max_attempts", policy.MaxAttempts,
+			"delay", delay,
+			"retry_after", retryAfter,
+			"error", err,
+		)
+		observeRetry(providerName, model)
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
 	}
 
-	return nil, fmt.Errorf("failed to get response from LLM after %d retries: %w", maxRetries, err)
+	return fmt.Errorf("failed to get response from LLM after %d attempts: %w", policy.MaxAttempts, err)
 }
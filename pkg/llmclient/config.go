@@ -1,7 +1,13 @@
 package llmclient
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -10,25 +16,67 @@ import (
 type ProviderConfig struct {
 	Name string `mapstructure:"-"`
 
+	Type string `mapstructure:"type"`
+
 	Model string `mapstructure:"model"`
 
 	APIKey string `mapstructure:"api_key"`
 
+	APIKeyEnv string `mapstructure:"api_key_env"`
+
+	APIKeyFile string `mapstructure:"api_key_file"`
+
 	BaseURL string `mapstructure:"base_url"`
 
 	OrgID string `mapstructure:"org_id"`
 
 	Temperature float64 `mapstructure:"temperature"`
+
+	MaxTokens int `mapstructure:"max_tokens"`
+
+	ContextLength int `mapstructure:"context_length"`
+
+	EmbeddingModel string `mapstructure:"embedding_model"`
+
+	Retry *RetryPolicy `mapstructure:"retry"`
+
+	RateLimit *RateLimit `mapstructure:"rate_limit"`
+
+	keyErr error
+}
+
+func (p ProviderConfig) RetryPolicy(common *RetryPolicy) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if common != nil {
+		policy = common.withDefaults(policy)
+	}
+	if p.Retry != nil {
+		policy = p.Retry.withDefaults(policy)
+	}
+	return policy
 }
 
 type AppConfig struct {
 	Common struct {
 		ActiveModel string `mapstructure:"active_model"`
+
+		ActiveVisionModel string `mapstructure:"active_vision_model"`
+
+		ActiveEmbeddingModel string `mapstructure:"active_embedding_model"`
+
+		Retry *RetryPolicy `mapstructure:"retry"`
+
+		Cache *CacheConfig `mapstructure:"cache"`
 	} `mapstructure:"common"`
 
 	Providers map[string]ProviderConfig `mapstructure:",remain"`
 }
 
+func LocalConfigPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".local" + ext
+}
+
 func LoadConfig(path string) (*AppConfig, error) {
 
 	v := viper.New()
@@ -40,11 +88,17 @@ func LoadConfig(path string) (*AppConfig, error) {
 	v.AutomaticEnv()
 
 	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
 
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-
-			return nil, fmt.Errorf("failed to read config file: %w", err)
+	localPath := LocalConfigPath(path)
+	if _, err := os.Stat(localPath); err == nil {
+		v.SetConfigFile(localPath)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to merge local config file %s: %w", localPath, err)
 		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to stat local config file %s: %w", localPath, err)
 	}
 
 	var config AppConfig
@@ -53,11 +107,217 @@ func LoadConfig(path string) (*AppConfig, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	baseDir := filepath.Dir(path)
+	var errs []error
+	if cache := config.Common.Cache; cache != nil && cache.Backend == "disk" {
+		if cache.Dir == "" {
+			cache.Dir = ".llm_cache"
+		}
+		if !filepath.IsAbs(cache.Dir) {
+			cache.Dir = filepath.Join(baseDir, cache.Dir)
+		}
+	}
 	for name, providerConf := range config.Providers {
 
 		providerConf.Name = name
+		providerConf.keyErr = providerConf.resolveAPIKey(baseDir)
 		config.Providers[name] = providerConf
 	}
 
+	if err := config.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid config %s:\n%w", path, errors.Join(errs...))
+	}
+
 	return &config, nil
 }
+
+func (p *ProviderConfig) resolveAPIKey(baseDir string) error {
+	if p.APIKeyEnv != "" || p.APIKeyFile != "" {
+		p.APIKey = ""
+	}
+
+	switch {
+	case p.APIKeyEnv != "":
+		key := strings.TrimSpace(os.Getenv(p.APIKeyEnv))
+		if key == "" {
+			return fmt.Errorf("[%s] environment variable %s referenced by api_key_env is not set", p.Name, p.APIKeyEnv)
+		}
+		p.APIKey = key
+	case p.APIKeyFile != "":
+		keyPath := p.APIKeyFile
+		if !filepath.IsAbs(keyPath) {
+			keyPath = filepath.Join(baseDir, keyPath)
+		}
+		content, err := os.ReadFile(keyPath)
+		if err != nil {
+			return fmt.Errorf("[%s] failed to read api_key_file: %w", p.Name, err)
+		}
+		key := strings.TrimSpace(string(content))
+		if key == "" {
+			return fmt.Errorf("[%s] api_key_file %s is empty", p.Name, keyPath)
+		}
+		p.APIKey = key
+	}
+	return nil
+}
+
+func (p ProviderConfig) credentialsError(kind providerType) error {
+	switch {
+	case p.keyErr != nil:
+		return p.keyErr
+	case p.APIKey == "" && p.APIKeyEnv == "" && p.APIKeyFile == "":
+		if !kind.local {
+			return fmt.Errorf("[%s] api_key is required (or set api_key_env / api_key_file)", p.Name)
+		}
+	case isPlaceholderKey(p.APIKey):
+		return fmt.Errorf("[%s] api_key %q is a placeholder", p.Name, p.APIKey)
+	}
+	return nil
+}
+
+func (c *AppConfig) CheckProvider(name string) error {
+	p, ok := c.Providers[name]
+	if !ok {
+		return fmt.Errorf("provider %q has no matching [%s] section", name, name)
+	}
+	if p.Name == "" {
+		p.Name = name
+	}
+	kind, known := providerTypes[p.Type]
+	if !known {
+		return fmt.Errorf("[%s] type %q is not supported (%s)", name, p.Type, strings.Join(supportedProviderTypes(), ", "))
+	}
+	return p.credentialsError(kind)
+}
+
+func (c *AppConfig) Validate() error {
+	var errs []error
+
+	if len(c.Providers) == 0 {
+		errs = append(errs, errors.New("no model providers configured"))
+	}
+	if c.Common.ActiveModel == "" {
+		errs = append(errs, errors.New("active_model is required"))
+	}
+	required := make(map[string]bool)
+	for _, ref := range []struct{ key, name string }{
+		{"active_model", c.Common.ActiveModel},
+		{"active_vision_model", c.Common.ActiveVisionModel},
+	} {
+		if ref.name == "" {
+			continue
+		}
+		if _, ok := c.Providers[ref.name]; !ok {
+			errs = append(errs, fmt.Errorf("%s %q has no matching [%s] section", ref.key, ref.name, ref.name))
+		}
+		required[ref.name] = true
+	}
+
+	if active := c.Common.ActiveEmbeddingModel; active != "" {
+		p, ok := c.Providers[active]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("active_embedding_model %q has no matching [%s] section", active, active))
+		case !providerTypes[p.Type].embeddings:
+			errs = append(errs, fmt.Errorf("active_embedding_model %q: provider type %q does not support embeddings", active, p.Type))
+		case p.EmbeddingModel == "":
+			errs = append(errs, fmt.Errorf("active_embedding_model %q: [%s] embedding_model is required", active, active))
+		}
+		required[active] = true
+	}
+
+	if c.Common.Cache != nil {
+		if err := c.Common.Cache.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	names := make([]string, 0, len(c.Providers))
+	for name := range c.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := c.Providers[name]
+		p.Name = name
+		kind, known := providerTypes[p.Type]
+		if !known {
+			errs = append(errs, fmt.Errorf("[%s] type %q is not supported (%s)", name, p.Type, strings.Join(supportedProviderTypes(), ", ")))
+			kind = providerTypes[ProviderOpenAI]
+		}
+		if p.Model == "" && !kind.modelOptional {
+			errs = append(errs, fmt.Errorf("[%s] model is required", name))
+		}
+		if err := p.credentialsError(kind); err != nil {
+			if required[name] {
+				errs = append(errs, err)
+			} else {
+				slog.Default().Warn("provider credentials are not usable; requests to it will fail", "component", "llmclient", "provider", name, "error", err)
+			}
+		}
+		if p.BaseURL != "" {
+			if u, err := url.Parse(p.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+				errs = append(errs, fmt.Errorf("[%s] base_url %q is not a valid URL", name, p.BaseURL))
+			}
+		}
+		if p.Temperature < 0 || p.Temperature > kind.maxTemperature {
+			errs = append(errs, fmt.Errorf("[%s] temperature %v is out of range [0, %v]", name, p.Temperature, kind.maxTemperature))
+		}
+		if p.MaxTokens < 0 {
+			errs = append(errs, fmt.Errorf("[%s] max_tokens must not be negative", name))
+		}
+		switch {
+		case p.ContextLength < 0:
+			errs = append(errs, fmt.Errorf("[%s] context_length must not be negative", name))
+		case p.ContextLength > 0 && p.MaxTokens >= p.ContextLength:
+			errs = append(errs, fmt.Errorf("[%s] max_tokens (%d) must be smaller than context_length (%d)", name, p.MaxTokens, p.ContextLength))
+		}
+		if err := p.RetryPolicy(c.Common.Retry).validate(); err != nil {
+			errs = append(errs, fmt.Errorf("[%s] %w", name, err))
+		}
+		if p.RateLimit != nil {
+			if err := p.RateLimit.validate(); err != nil {
+				errs = append(errs, fmt.Errorf("[%s] %w", name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type providerType struct {
+	maxTemperature float64
+	local          bool
+	modelOptional  bool
+	embeddings     bool
+}
+
+var providerTypes = map[string]providerType{
+	"":                {maxTemperature: 2, embeddings: true},
+	ProviderOpenAI:    {maxTemperature: 2, embeddings: true},
+	ProviderAnthropic: {maxTemperature: 1},
+	ProviderOllama:    {maxTemperature: 2, local: true, embeddings: true},
+	ProviderLlamaCpp:  {maxTemperature: 2, local: true, modelOptional: true, embeddings: true},
+}
+
+func supportedProviderTypes() []string {
+	names := make([]string, 0, len(providerTypes))
+	for name := range providerTypes {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isPlaceholderKey(key string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(key))
+	return strings.HasPrefix(upper, "YOUR_") ||
+		strings.HasPrefix(upper, "<") && strings.HasSuffix(upper, ">") ||
+		upper == "CHANGEME" || upper == "XXX"
+}
@@ -0,0 +1,110 @@
+package llmclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const llamaCppDefaultBaseURL = "http://localhost:8080"
+
+type llamaCppProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func newLlamaCppProvider(conf ProviderConfig, httpClient *http.Client) *llamaCppProvider {
+	baseURL := conf.BaseURL
+	if baseURL == "" {
+		baseURL = llamaCppDefaultBaseURL
+	}
+	return &llamaCppProvider{
+		baseURL:    strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1"),
+		apiKey:     conf.APIKey,
+		httpClient: httpClient,
+	}
+}
+
+type llamaCppRequest struct {
+	openai.ChatCompletionRequest
+	Grammar    string         `json:"grammar,omitempty"`
+	JSONSchema map[string]any `json:"json_schema,omitempty"`
+}
+
+type llamaCppError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *llamaCppProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	body := llamaCppRequest{ChatCompletionRequest: openaiRequest(req)}
+	if req.Format != nil {
+		if req.Format.Grammar != "" {
+			body.Grammar = req.Format.Grammar
+		} else {
+			body.JSONSchema = req.Format.Schema
+		}
+	}
+
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	status, data, err := postJSON(ctx, p.httpClient, p.baseURL+"/v1/chat/completions", header, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, p.apiError(status, data)
+	}
+
+	var resp openai.ChatCompletionResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode llama.cpp response: %w", err)
+	}
+	return openaiResponse(resp)
+}
+
+func (p *llamaCppProvider) Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	body := openai.EmbeddingRequestStrings{Input: req.Input, Model: openai.EmbeddingModel(req.Model)}
+	status, data, err := postJSON(ctx, p.httpClient, p.baseURL+"/v1/embeddings", header, body)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, p.apiError(status, data)
+	}
+
+	var resp openai.EmbeddingResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode llama.cpp embedding response: %w", err)
+	}
+	return openaiEmbeddingResponse(resp), nil
+}
+
+func (p *llamaCppProvider) apiError(status int, data []byte) error {
+	apiErr := &APIError{Provider: ProviderLlamaCpp, StatusCode: status, Type: "error"}
+	var errBody llamaCppError
+	if json.Unmarshal(data, &errBody) == nil && errBody.Error.Message != "" {
+		apiErr.Type = errBody.Error.Type
+		apiErr.Message = errBody.Error.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	return apiErr
+}
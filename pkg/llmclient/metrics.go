@@ -0,0 +1,90 @@
+package llmclient
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"hivemind-go/pkg/metrics"
+)
+
+func observeRequest(provider, model string, elapsed time.Duration, err error) {
+	m := metrics.Default()
+
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+		m.Counter("hivemind_llm_errors_total", "LLM request errors by provider and error code.", "provider", "model", "code").
+			Add(1, provider, model, errorCode(err))
+	}
+	m.Counter("hivemind_llm_requests_total", "LLM request attempts by provider and outcome.", "provider", "model", "outcome").
+		Add(1, provider, model, outcome)
+	m.Histogram("hivemind_llm_request_duration_seconds", "LLM request latency per attempt.", metrics.DefaultBuckets, "provider", "model").
+		Observe(elapsed.Seconds(), provider, model)
+}
+
+func observeRetry(provider, model string) {
+	metrics.Default().Counter("hivemind_llm_retries_total", "LLM request retries by provider.", "provider", "model").
+		Add(1, provider, model)
+}
+
+func observeUsage(provider, model string, usage Usage) {
+	tokens := metrics.Default().Counter("hivemind_llm_tokens_total", "Tokens consumed by provider and token type.", "provider", "model", "type")
+	tokens.Add(float64(usage.PromptTokens), provider, model, "prompt")
+	tokens.Add(float64(usage.CompletionTokens), provider, model, "completion")
+}
+
+func errorCode(err error) string {
+	var providerErr *APIError
+	if errors.As(err, &providerErr) && providerErr.StatusCode != 0 {
+		return strconv.Itoa(providerErr.StatusCode)
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode != 0 {
+		return strconv.Itoa(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0 {
+		return strconv.Itoa(reqErr.HTTPStatusCode)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return "canceled"
+	}
+	return "error"
+}
+
+func observeReload(success bool) {
+	outcome := "success"
+	if !success {
+		outcome = "rejected"
+	}
+	metrics.Default().Counter("hivemind_config_reloads_total", "LLM configuration reloads by outcome.", "outcome").
+		Add(1, outcome)
+}
+
+func observeQueueWait(provider string, wait time.Duration) {
+	metrics.Default().Histogram("hivemind_llm_queue_wait_seconds", "Time LLM requests spent waiting for the provider rate limiter.", metrics.DefaultBuckets, "provider").
+		Observe(wait.Seconds(), provider)
+}
+
+func observeQueueLength(provider string, queued int) {
+	metrics.Default().Gauge("hivemind_llm_queue_length", "LLM requests waiting for the provider rate limiter.", "provider").
+		Set(float64(queued), provider)
+}
+
+func observeInFlight(provider string, inFlight int) {
+	metrics.Default().Gauge("hivemind_llm_in_flight", "LLM requests currently in flight per provider.", "provider").
+		Set(float64(inFlight), provider)
+}
+
+func observeCache(provider, model string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	metrics.Default().Counter("hivemind_llm_cache_total", "LLM response cache lookups by result.", "provider", "model", "result").
+		Add(1, provider, model, result)
+}
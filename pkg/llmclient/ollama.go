@@ -0,0 +1,202 @@
+package llmclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const ollamaDefaultBaseURL = "http://localhost:11434"
+
+type ollamaProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func newOllamaProvider(conf ProviderConfig, httpClient *http.Client) *ollamaProvider {
+	baseURL := conf.BaseURL
+	if baseURL == "" {
+		baseURL = ollamaDefaultBaseURL
+	}
+	return &ollamaProvider{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     conf.APIKey,
+		httpClient: httpClient,
+	}
+}
+
+type ollamaFunctionCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type ollamaToolCall struct {
+	Function ollamaFunctionCall `json:"function"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+type ollamaTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description,omitempty"`
+		Parameters  map[string]any `json:"parameters,omitempty"`
+	} `json:"function"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   any             `json:"format,omitempty"`
+	Options  ollamaOptions   `json:"options"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message         ollamaMessage `json:"message"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+func (p *ollamaProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	status, data, err := postJSON(ctx, p.httpClient, p.baseURL+"/api/chat", header, p.buildRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, ollamaError(status, data)
+	}
+
+	var resp ollamaResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama response: %w", err)
+	}
+	return resp.toResponse(), nil
+}
+
+func (p *ollamaProvider) Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	body := struct {
+		Model string   `json:"model"`
+		Input []string `json:"input"`
+	}{req.Model, req.Input}
+	status, data, err := postJSON(ctx, p.httpClient, p.baseURL+"/api/embed", header, body)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, ollamaError(status, data)
+	}
+
+	var resp struct {
+		Embeddings      [][]float32 `json:"embeddings"`
+		PromptEvalCount int         `json:"prompt_eval_count"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama embedding response: %w", err)
+	}
+	return &EmbeddingResponse{
+		Vectors: resp.Embeddings,
+		Usage:   Usage{PromptTokens: resp.PromptEvalCount, TotalTokens: resp.PromptEvalCount},
+	}, nil
+}
+
+func ollamaError(status int, data []byte) error {
+	apiErr := &APIError{Provider: ProviderOllama, StatusCode: status, Type: "error"}
+	var errBody struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &errBody) == nil && errBody.Error != "" {
+		apiErr.Message = errBody.Error
+	} else {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	return apiErr
+}
+
+func (p *ollamaProvider) buildRequest(req Request) ollamaRequest {
+	out := ollamaRequest{
+		Model: req.Model,
+		Options: ollamaOptions{
+			Temperature: req.Temperature,
+			NumPredict:  req.MaxTokens,
+		},
+	}
+
+	for _, msg := range req.Messages {
+		m := ollamaMessage{Role: msg.Role, Content: msg.Content}
+		for _, call := range msg.ToolCalls {
+			args := json.RawMessage(call.Arguments)
+			if !json.Valid(args) {
+				args = json.RawMessage("{}")
+			}
+			m.ToolCalls = append(m.ToolCalls, ollamaToolCall{Function: ollamaFunctionCall{Name: call.Name, Arguments: args}})
+		}
+		out.Messages = append(out.Messages, m)
+	}
+
+	for _, spec := range req.Tools {
+		tool := ollamaTool{Type: "function"}
+		tool.Function.Name = spec.Name
+		tool.Function.Description = spec.Description
+		tool.Function.Parameters = spec.InputSchema
+		out.Tools = append(out.Tools, tool)
+	}
+
+	if req.Format != nil {
+		if req.Format.Schema != nil {
+			out.Format = req.Format.Schema
+		} else {
+			out.Format = "json"
+		}
+	}
+	return out
+}
+
+func (r *ollamaResponse) toResponse() *Response {
+	resp := &Response{
+		Content:      r.Message.Content,
+		FinishReason: r.DoneReason,
+		Usage: Usage{
+			PromptTokens:     r.PromptEvalCount,
+			CompletionTokens: r.EvalCount,
+			TotalTokens:      r.PromptEvalCount + r.EvalCount,
+		},
+	}
+	for i, call := range r.Message.ToolCalls {
+		resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+			ID:        fmt.Sprintf("call_%d", i),
+			Name:      call.Function.Name,
+			Arguments: string(call.Function.Arguments),
+		})
+	}
+	if len(resp.ToolCalls) > 0 {
+		resp.FinishReason = FinishToolCalls
+	}
+	return resp
+}
@@ -0,0 +1,129 @@
+package llmclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type openaiProvider struct {
+	client *openai.Client
+}
+
+func newOpenAIProvider(conf ProviderConfig, httpClient *http.Client) *openaiProvider {
+	clientConfig := openai.DefaultConfig(conf.APIKey)
+
+	if conf.BaseURL != "" {
+		clientConfig.BaseURL = conf.BaseURL
+	}
+
+	if conf.OrgID != "" {
+		clientConfig.OrgID = conf.OrgID
+	}
+	clientConfig.HTTPClient = httpClient
+
+	return &openaiProvider{client: openai.NewClientWithConfig(clientConfig)}
+}
+
+func (p *openaiProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	resp, err := p.client.CreateChatCompletion(ctx, openaiRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return openaiResponse(resp)
+}
+
+func openaiRequest(req Request) openai.ChatCompletionRequest {
+	apiMessages := make([]openai.ChatCompletionMessage, len(req.Messages))
+	for i, msg := range req.Messages {
+		apiMessages[i] = openai.ChatCompletionMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+		}
+		for _, call := range msg.ToolCalls {
+			apiMessages[i].ToolCalls = append(apiMessages[i].ToolCalls, openai.ToolCall{
+				ID:   call.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      call.Name,
+					Arguments: call.Arguments,
+				},
+			})
+		}
+	}
+
+	apiReq := openai.ChatCompletionRequest{
+		Model:       req.Model,
+		Messages:    apiMessages,
+		Temperature: float32(req.Temperature),
+		MaxTokens:   req.MaxTokens,
+	}
+	for _, tool := range req.Tools {
+		apiReq.Tools = append(apiReq.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.InputSchema,
+			},
+		})
+	}
+	return apiReq
+}
+
+func openaiResponse(resp openai.ChatCompletionResponse) (*Response, error) {
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("LLM response contains no choices")
+	}
+
+	choice := resp.Choices[0]
+	result := &Response{
+		Content:      choice.Message.Content,
+		FinishReason: string(choice.FinishReason),
+		Usage: Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}
+	for _, call := range choice.Message.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return result, nil
+}
+
+func (p *openaiProvider) Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	resp, err := p.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: req.Input,
+		Model: openai.EmbeddingModel(req.Model),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return openaiEmbeddingResponse(resp), nil
+}
+
+func openaiEmbeddingResponse(resp openai.EmbeddingResponse) *EmbeddingResponse {
+	result := &EmbeddingResponse{
+		Vectors: make([][]float32, len(resp.Data)),
+		Usage: Usage{
+			PromptTokens: resp.Usage.PromptTokens,
+			TotalTokens:  resp.Usage.TotalTokens,
+		},
+	}
+	for i, item := range resp.Data {
+		if item.Index >= 0 && item.Index < len(result.Vectors) {
+			result.Vectors[item.Index] = item.Embedding
+		} else {
+			result.Vectors[i] = item.Embedding
+		}
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package llmclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type Provider interface {
+	Complete(ctx context.Context, req Request) (*Response, error)
+}
+
+type Embedder interface {
+	Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error)
+}
+
+type EmbeddingRequest struct {
+	Model string
+	Input []string
+}
+
+type EmbeddingResponse struct {
+	Vectors [][]float32
+	Usage   Usage
+}
+
+type Request struct {
+	Model       string
+	Messages    []Message
+	Temperature float64
+	MaxTokens   int
+	Tools       []ToolSpec
+	Format      *ResponseFormat
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type ToolSpec struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema,omitempty"`
+}
+
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+const (
+	FinishStop      = "stop"
+	FinishLength    = "length"
+	FinishToolCalls = "tool_calls"
+)
+
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error (status %d, %s): %s", e.Provider, e.StatusCode, e.Type, e.Message)
+}
+
+type ResponseFormat struct {
+	Name    string         `json:"name,omitempty"`
+	Schema  map[string]any `json:"schema,omitempty"`
+	Grammar string         `json:"grammar,omitempty"`
+}
+
+type toolsKey struct{}
+
+func WithTools(ctx context.Context, tools ...ToolSpec) context.Context {
+	return context.WithValue(ctx, toolsKey{}, tools)
+}
+
+func toolsFromContext(ctx context.Context) []ToolSpec {
+	tools, _ := ctx.Value(toolsKey{}).([]ToolSpec)
+	return tools
+}
+
+type responseFormatKey struct{}
+
+func WithResponseFormat(ctx context.Context, format ResponseFormat) context.Context {
+	return context.WithValue(ctx, responseFormatKey{}, &format)
+}
+
+func responseFormatFromContext(ctx context.Context) *ResponseFormat {
+	format, _ := ctx.Value(responseFormatKey{}).(*ResponseFormat)
+	return format
+}
+
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderOllama    = "ollama"
+	ProviderLlamaCpp  = "llamacpp"
+)
+
+func newProvider(conf ProviderConfig, httpClient *http.Client) (Provider, error) {
+	switch conf.Type {
+	case "", ProviderOpenAI:
+		return newOpenAIProvider(conf, httpClient), nil
+	case ProviderAnthropic:
+		return newAnthropicProvider(conf, httpClient), nil
+	case ProviderOllama:
+		return newOllamaProvider(conf, httpClient), nil
+	case ProviderLlamaCpp:
+		return newLlamaCppProvider(conf, httpClient), nil
+	default:
+		return nil, fmt.Errorf("unknown provider type %q", conf.Type)
+	}
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, body any) (int, []byte, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, data, nil
+}
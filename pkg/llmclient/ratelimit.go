@@ -0,0 +1,249 @@
+package llmclient
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+type RateLimit struct {
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+
+	TokensPerMinute int `mapstructure:"tokens_per_minute"`
+
+	MaxInFlight int `mapstructure:"max_in_flight"`
+}
+
+func (r RateLimit) enabled() bool {
+	return r.RequestsPerMinute > 0 || r.TokensPerMinute > 0 || r.MaxInFlight > 0
+}
+
+func (r RateLimit) validate() error {
+	if r.RequestsPerMinute < 0 || r.TokensPerMinute < 0 || r.MaxInFlight < 0 {
+		return fmt.Errorf("rate_limit values must not be negative")
+	}
+	return nil
+}
+
+type bucket struct {
+	capacity float64
+	tokens   float64
+	perSec   float64
+	updated  time.Time
+}
+
+func newBucket(perMinute int, now time.Time) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		perSec:   float64(perMinute) / 60,
+		updated:  now,
+	}
+}
+
+func (b *bucket) resize(perMinute int, now time.Time) *bucket {
+	if b == nil || perMinute <= 0 {
+		return newBucket(perMinute, now)
+	}
+	b.refill(now)
+	b.capacity = float64(perMinute)
+	b.tokens = math.Min(b.capacity, b.tokens)
+	b.perSec = float64(perMinute) / 60
+	return b
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b == nil {
+		return
+	}
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.updated).Seconds()*b.perSec)
+	b.updated = now
+}
+
+func (b *bucket) wait(need float64) time.Duration {
+	if b == nil {
+		return 0
+	}
+	need = math.Min(need, b.capacity)
+	if b.tokens >= need {
+		return 0
+	}
+	return time.Duration((need - b.tokens) / b.perSec * float64(time.Second))
+}
+
+func (b *bucket) take(n float64) {
+	if b != nil {
+		b.tokens -= n
+	}
+}
+
+type waiter struct {
+	tokens  int
+	ready   chan struct{}
+	granted bool
+}
+
+type limiter struct {
+	provider string
+
+	mu       sync.Mutex
+	config   RateLimit
+	requests *bucket
+	tokens   *bucket
+	inFlight int
+	queue    []*waiter
+	timer    *time.Timer
+}
+
+func newLimiter(provider string, config RateLimit) *limiter {
+	l := &limiter{provider: provider}
+	l.configure(config)
+	return l
+}
+
+func (l *limiter) configure(config RateLimit) {
+	now := time.Now()
+	l.config = config
+	l.requests = l.requests.resize(config.RequestsPerMinute, now)
+	l.tokens = l.tokens.resize(config.TokensPerMinute, now)
+}
+
+func (l *limiter) acquire(ctx context.Context, tokens int) (func(actual int), error) {
+	start := time.Now()
+	w := &waiter{tokens: tokens, ready: make(chan struct{})}
+
+	l.mu.Lock()
+	l.queue = append(l.queue, w)
+	l.dispatchLocked()
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		l.mu.Lock()
+		if w.granted {
+			l.mu.Unlock()
+			l.release(w.tokens, 0)
+		} else {
+			l.removeLocked(w)
+			l.dispatchLocked()
+			l.mu.Unlock()
+		}
+		observeQueueWait(l.provider, time.Since(start))
+		return nil, ctx.Err()
+	}
+
+	observeQueueWait(l.provider, time.Since(start))
+	var once sync.Once
+	return func(actual int) {
+		once.Do(func() { l.release(w.tokens, actual) })
+	}, nil
+}
+
+func (l *limiter) release(estimated, actual int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if actual > 0 && l.tokens != nil {
+		l.tokens.refill(time.Now())
+		l.tokens.take(float64(actual - estimated))
+	}
+	l.dispatchLocked()
+	observeInFlight(l.provider, l.inFlight)
+}
+
+func (l *limiter) dispatchLocked() {
+	defer func() { observeQueueLength(l.provider, len(l.queue)) }()
+
+	for len(l.queue) > 0 {
+		head := l.queue[0]
+
+		if l.config.MaxInFlight > 0 && l.inFlight >= l.config.MaxInFlight {
+			return
+		}
+
+		now := time.Now()
+		l.requests.refill(now)
+		l.tokens.refill(now)
+		if wait := max(l.requests.wait(1), l.tokens.wait(float64(head.tokens))); wait > 0 {
+			l.scheduleLocked(wait)
+			return
+		}
+
+		l.requests.take(1)
+		l.tokens.take(float64(head.tokens))
+		l.inFlight++
+		observeInFlight(l.provider, l.inFlight)
+
+		head.granted = true
+		close(head.ready)
+		l.queue = l.queue[1:]
+	}
+}
+
+func (l *limiter) scheduleLocked(wait time.Duration) {
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	l.timer = time.AfterFunc(wait, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.timer = nil
+		l.dispatchLocked()
+	})
+}
+
+func (l *limiter) removeLocked(w *waiter) {
+	for i, queued := range l.queue {
+		if queued == w {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+type limiterSet struct {
+	mu       sync.Mutex
+	limiters map[string]*limiter
+}
+
+func newLimiterSet() *limiterSet {
+	return &limiterSet{limiters: make(map[string]*limiter)}
+}
+
+func (s *limiterSet) get(provider string, config *RateLimit) *limiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.limiters[provider]
+	if config == nil || !config.enabled() {
+		if ok {
+			l.mu.Lock()
+			l.configure(RateLimit{})
+			l.dispatchLocked()
+			l.mu.Unlock()
+			delete(s.limiters, provider)
+		}
+		return nil
+	}
+
+	if !ok {
+		l = newLimiter(provider, *config)
+		s.limiters[provider] = l
+		return l
+	}
+
+	l.mu.Lock()
+	if l.config != *config {
+		l.configure(*config)
+		l.dispatchLocked()
+	}
+	l.mu.Unlock()
+	return l
+}
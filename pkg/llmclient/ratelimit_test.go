@@ -0,0 +1,183 @@
+package llmclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	start := time.Unix(0, 0)
+
+	tests := []struct {
+		name      string
+		perMinute int
+		take      float64
+		elapsed   time.Duration
+		need      float64
+		wantWait  time.Duration
+	}{
+		{name: "full bucket", perMinute: 60, need: 1, wantWait: 0},
+		{name: "empty bucket", perMinute: 60, take: 60, need: 1, wantWait: time.Second},
+		{name: "refills over time", perMinute: 60, take: 60, elapsed: 500 * time.Millisecond, need: 1, wantWait: 500 * time.Millisecond},
+		{name: "refill capped at capacity", perMinute: 60, take: 10, elapsed: time.Hour, need: 60, wantWait: 0},
+		{name: "need clamped to capacity", perMinute: 60, take: 30, need: 1000, wantWait: 30 * time.Second},
+		{name: "overdrawn bucket", perMinute: 60, take: 90, need: 1, wantWait: 31 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBucket(tt.perMinute, start)
+			b.take(tt.take)
+			b.refill(start.Add(tt.elapsed))
+			if got := b.wait(tt.need); got != tt.wantWait {
+				t.Errorf("wait(%v) = %s, want %s", tt.need, got, tt.wantWait)
+			}
+		})
+	}
+
+	var disabled *bucket
+	disabled.take(1)
+	disabled.refill(start)
+	if got := disabled.wait(1e9); got != 0 {
+		t.Errorf("disabled bucket wait = %s, want 0", got)
+	}
+}
+
+func TestBucketResize(t *testing.T) {
+	start := time.Unix(0, 0)
+
+	tests := []struct {
+		name       string
+		perMinute  int
+		take       float64
+		resizeTo   int
+		wantNil    bool
+		wantTokens float64
+	}{
+		{name: "keeps remaining budget", perMinute: 100, take: 90, resizeTo: 200, wantTokens: 10},
+		{name: "clamps to smaller capacity", perMinute: 100, take: 10, resizeTo: 50, wantTokens: 50},
+		{name: "keeps debt", perMinute: 100, take: 150, resizeTo: 100, wantTokens: -50},
+		{name: "disabling removes bucket", perMinute: 100, resizeTo: 0, wantNil: true},
+		{name: "enabling starts full", perMinute: 0, resizeTo: 30, wantTokens: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBucket(tt.perMinute, start)
+			b.take(tt.take)
+			got := b.resize(tt.resizeTo, start)
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("resize(%d) = %+v, want nil", tt.resizeTo, got)
+				}
+				return
+			}
+			if got.tokens != tt.wantTokens || got.capacity != float64(tt.resizeTo) {
+				t.Errorf("resize(%d) = tokens %v capacity %v, want tokens %v capacity %d", tt.resizeTo, got.tokens, got.capacity, tt.wantTokens, tt.resizeTo)
+			}
+		})
+	}
+}
+
+func TestLimiterQueuesInOrder(t *testing.T) {
+	l := newLimiter("test", RateLimit{MaxInFlight: 1})
+
+	release, err := l.acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	granted := make(chan int, 3)
+	for i := 1; i <= 3; i++ {
+		go func() {
+			release, err := l.acquire(context.Background(), 0)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			granted <- i
+			release(0)
+		}()
+		waitForQueue(t, l, i)
+	}
+
+	release(0)
+	for want := 1; want <= 3; want++ {
+		select {
+		case got := <-granted:
+			if got != want {
+				t.Fatalf("waiter %d granted, want %d", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("waiter %d was never granted", want)
+		}
+	}
+}
+
+func TestLimiterCancelledWaiterLeavesQueue(t *testing.T) {
+	l := newLimiter("test", RateLimit{MaxInFlight: 1})
+	release, err := l.acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.acquire(ctx, 0)
+		done <- err
+	}()
+	waitForQueue(t, l, 1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("acquire error = %v, want context.Canceled", err)
+	}
+	waitForQueue(t, l, 0)
+
+	release(0)
+	if _, err := l.acquire(context.Background(), 0); err != nil {
+		t.Fatalf("acquire after cancelled waiter: %v", err)
+	}
+}
+
+func TestLimiterSetReconfigure(t *testing.T) {
+	s := newLimiterSet()
+	config := &RateLimit{RequestsPerMinute: 10}
+
+	l := s.get("p", config)
+	for i := 0; i < 10; i++ {
+		release, err := l.acquire(context.Background(), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release(0)
+	}
+
+	if again := s.get("p", &RateLimit{RequestsPerMinute: 20}); again != l {
+		t.Fatal("reconfigure replaced the limiter")
+	}
+	if l.requests.tokens >= 1 {
+		t.Errorf("reconfigure refilled the bucket to %v tokens", l.requests.tokens)
+	}
+
+	if s.get("p", &RateLimit{}) != nil || s.get("p", nil) != nil {
+		t.Error("disabled rate limit returned a limiter")
+	}
+}
+
+func waitForQueue(t *testing.T, l *limiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		l.mu.Lock()
+		queued := len(l.queue)
+		l.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("queue length = %d, want %d", queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,168 @@
+package llmclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type RetryPolicy struct {
+	MaxAttempts int `mapstructure:"max_attempts"`
+
+	BaseDelay time.Duration `mapstructure:"base_delay"`
+
+	MaxDelay time.Duration `mapstructure:"max_delay"`
+
+	Jitter *float64 `mapstructure:"jitter"`
+
+	RetryableStatus []int `mapstructure:"retryable_status"`
+}
+
+const StatusOverloaded = 529
+
+var defaultJitter = 0.2
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      &defaultJitter,
+		RetryableStatus: []int{
+			http.StatusRequestTimeout,
+			http.StatusConflict,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+			StatusOverloaded,
+		},
+	}
+}
+
+func (p RetryPolicy) withDefaults(fallback RetryPolicy) RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = fallback.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = fallback.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = fallback.MaxDelay
+	}
+	if p.Jitter == nil {
+		p.Jitter = fallback.Jitter
+	}
+	if p.RetryableStatus == nil {
+		p.RetryableStatus = fallback.RetryableStatus
+	}
+	return p
+}
+
+func (p RetryPolicy) Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var providerErr *APIError
+	if errors.As(err, &providerErr) && providerErr.StatusCode != 0 {
+		return slices.Contains(p.RetryableStatus, providerErr.StatusCode)
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode != 0 {
+		return slices.Contains(p.RetryableStatus, apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0 {
+		return slices.Contains(p.RetryableStatus, reqErr.HTTPStatusCode)
+	}
+	return true
+}
+
+func (p RetryPolicy) Delay(attempt int, retryAfter time.Duration) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxDelay)
+	if p.Jitter != nil && *p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * *p.Jitter * float64(delay))
+	}
+	return max(delay, retryAfter)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type retryAfterKey struct{}
+
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if holder, ok := req.Context().Value(retryAfterKey{}).(*time.Duration); ok {
+		*holder = parseRetryAfter(resp.Header, time.Now())
+	}
+	return resp, nil
+}
+
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	if ms := header.Get("Retry-After-Ms"); ms != "" {
+		if n, err := strconv.ParseFloat(ms, 64); err == nil && n > 0 {
+			return time.Duration(n * float64(time.Millisecond))
+		}
+	}
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		return 0
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+func (p RetryPolicy) validate() error {
+	var errs []error
+	if p.MaxDelay < p.BaseDelay {
+		errs = append(errs, fmt.Errorf("retry.max_delay %s is less than retry.base_delay %s", p.MaxDelay, p.BaseDelay))
+	}
+	if p.Jitter != nil && (*p.Jitter < 0 || *p.Jitter > 1) {
+		errs = append(errs, fmt.Errorf("retry.jitter %v is out of range [0, 1]", *p.Jitter))
+	}
+	for _, status := range p.RetryableStatus {
+		if status < 100 || status > 599 {
+			errs = append(errs, fmt.Errorf("retry.retryable_status contains invalid HTTP status %d", status))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,59 @@
+package llmclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	zero := 0.0
+	half := 0.5
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second, Jitter: &zero}
+
+	tests := []struct {
+		name       string
+		jitter     *float64
+		attempt    int
+		retryAfter time.Duration
+		min, max   time.Duration
+	}{
+		{name: "first attempt", attempt: 1, min: time.Second, max: time.Second},
+		{name: "exponential backoff", attempt: 3, min: 4 * time.Second, max: 4 * time.Second},
+		{name: "capped at max delay", attempt: 10, min: 5 * time.Second, max: 5 * time.Second},
+		{name: "retry after below backoff", attempt: 2, retryAfter: time.Second, min: 2 * time.Second, max: 2 * time.Second},
+		{name: "retry after above backoff", attempt: 1, retryAfter: 3 * time.Second, min: 3 * time.Second, max: 3 * time.Second},
+		{name: "retry after above cap", attempt: 1, retryAfter: 60 * time.Second, min: 60 * time.Second, max: 60 * time.Second},
+		{name: "jitter shortens delay", jitter: &half, attempt: 2, min: time.Second, max: 2 * time.Second},
+		{name: "jitter applied after cap", jitter: &half, attempt: 10, min: 2500 * time.Millisecond, max: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := policy
+			if tt.jitter != nil {
+				p.Jitter = tt.jitter
+			}
+			for i := 0; i < 20; i++ {
+				got := p.Delay(tt.attempt, tt.retryAfter)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("Delay(%d, %s) = %s, want between %s and %s", tt.attempt, tt.retryAfter, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyWithDefaults(t *testing.T) {
+	zero := 0.0
+	got := RetryPolicy{Jitter: &zero}.withDefaults(DefaultRetryPolicy())
+	if got.Jitter == nil || *got.Jitter != 0 {
+		t.Fatalf("explicit jitter = 0 was replaced with %v", got.Jitter)
+	}
+
+	got = RetryPolicy{}.withDefaults(DefaultRetryPolicy())
+	if got.Jitter == nil || *got.Jitter != defaultJitter {
+		t.Fatalf("unset jitter = %v, want %v", got.Jitter, defaultJitter)
+	}
+	if !got.Retryable(&APIError{StatusCode: StatusOverloaded}) {
+		t.Fatalf("default policy does not retry status %d", StatusOverloaded)
+	}
+}
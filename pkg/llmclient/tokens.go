@@ -0,0 +1,45 @@
+package llmclient
+
+import "unicode/utf8"
+
+const messageOverheadTokens = 4
+
+func EstimateTokens(messages []Message) int {
+	total := 0
+	for _, m := range messages {
+		total += messageOverheadTokens + estimateTextTokens(m.Content)
+		for _, call := range m.ToolCalls {
+			total += estimateTextTokens(call.Name) + estimateTextTokens(call.Arguments)
+		}
+	}
+	return total
+}
+
+func estimateTextTokens(text string) int {
+	ascii, wide := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			wide++
+		}
+	}
+	return (ascii+3)/4 + wide
+}
+
+func (c *LLMClient) ContextBudget() (int, bool) {
+	state := c.state.Load()
+	providerConf, ok := state.config.Providers[c.providerName(state)]
+	if !ok || providerConf.ContextLength <= 0 {
+		return 0, false
+	}
+	return providerConf.ContextBudget(), true
+}
+
+func (p ProviderConfig) ContextBudget() int {
+	reserve := p.MaxTokens
+	if reserve <= 0 {
+		reserve = min(4096, p.ContextLength/4)
+	}
+	return p.ContextLength - reserve
+}
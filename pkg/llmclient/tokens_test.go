@@ -0,0 +1,54 @@
+package llmclient
+
+import "testing"
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []Message
+		want     int
+	}{
+		{name: "empty", want: 0},
+		{name: "overhead only", messages: []Message{{Role: "user"}}, want: 4},
+		{name: "ascii rounds up", messages: []Message{{Content: "hello"}}, want: 4 + 2},
+		{name: "wide runes count one each", messages: []Message{{Content: "你好世界"}}, want: 4 + 4},
+		{name: "mixed", messages: []Message{{Content: "abcd你好"}}, want: 4 + 1 + 2},
+		{name: "tool calls", messages: []Message{{ToolCalls: []ToolCall{{Name: "Search", Arguments: `{"q":"go"}`}}}}, want: 4 + 2 + 3},
+		{name: "sums messages", messages: []Message{{Content: "abcd"}, {Content: "abcd"}}, want: 2 * (4 + 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateTokens(tt.messages); got != tt.want {
+				t.Errorf("EstimateTokens = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider ProviderConfig
+		want     int
+		ok       bool
+	}{
+		{name: "unknown context length", provider: ProviderConfig{MaxTokens: 1000}, ok: false},
+		{name: "reserves max_tokens", provider: ProviderConfig{ContextLength: 8000, MaxTokens: 1000}, want: 7000, ok: true},
+		{name: "reserves a quarter of small windows", provider: ProviderConfig{ContextLength: 8000}, want: 6000, ok: true},
+		{name: "reserve is capped", provider: ProviderConfig{ContextLength: 128000}, want: 128000 - 4096, ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := anthropicTestConfig("http://127.0.0.1:0")
+			provider := config.Providers["claude"]
+			provider.ContextLength = tt.provider.ContextLength
+			provider.MaxTokens = tt.provider.MaxTokens
+			config.Providers["claude"] = provider
+
+			got, ok := NewLLMClient(config).ContextBudget()
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ContextBudget = %d, %v; want %d, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
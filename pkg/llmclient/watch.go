@@ -0,0 +1,110 @@
+package llmclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const reloadDebounce = 250 * time.Millisecond
+
+func (c *LLMClient) WatchConfig(ctx context.Context, path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve config path %s: %w", path, err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch config directory %s: %w", filepath.Dir(absPath), err)
+	}
+
+	targets := map[string]bool{
+		absPath:                  true,
+		LocalConfigPath(absPath): true,
+	}
+	go c.watch(ctx, watcher, absPath, targets)
+	return nil
+}
+
+func (c *LLMClient) watch(ctx context.Context, watcher *fsnotify.Watcher, path string, targets map[string]bool) {
+	defer watcher.Close()
+
+	logger := slog.Default().With("component", "llmclient", "config", path)
+	var debounce <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !targets[filepath.Clean(event.Name)] || event.Op == fsnotify.Chmod {
+				continue
+			}
+			debounce = time.After(reloadDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Warn("config watcher error", "error", err)
+		case <-debounce:
+			debounce = nil
+			c.reloadFile(path, logger)
+		}
+	}
+}
+
+func (c *LLMClient) reloadFile(path string, logger *slog.Logger) {
+	config, err := LoadConfig(path)
+	if err != nil {
+		observeReload(false)
+		logger.Error("rejected invalid config reload, keeping previous configuration", "error", err)
+		return
+	}
+
+	previous := c.Reload(config)
+	observeReload(true)
+	logger.Info("reloaded LLM configuration",
+		"active_model", config.Common.ActiveModel,
+		"changes", diffProviders(previous, config),
+	)
+}
+
+func diffProviders(previous, current *AppConfig) []string {
+	var changes []string
+	if previous.Common.ActiveModel != current.Common.ActiveModel {
+		changes = append(changes, fmt.Sprintf("active_model %s -> %s", previous.Common.ActiveModel, current.Common.ActiveModel))
+	}
+	if !reflect.DeepEqual(previous.Common.Retry, current.Common.Retry) {
+		changes = append(changes, "common.retry")
+	}
+	for name, conf := range current.Providers {
+		old, ok := previous.Providers[name]
+		switch {
+		case !ok:
+			changes = append(changes, "+"+name)
+		case !reflect.DeepEqual(old, conf):
+			changes = append(changes, "~"+name)
+		}
+	}
+	for name := range previous.Providers {
+		if _, ok := current.Providers[name]; !ok {
+			changes = append(changes, "-"+name)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
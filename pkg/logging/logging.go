@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+const MessagesFile = "messages.log"
+
+type Rotation struct {
+	MaxSizeBytes int64
+
+	MaxBackups int
+}
+
+type Config struct {
+	Handler slog.Handler
+
+	Level slog.Leveler
+
+	Dir string
+
+	Stdout bool
+
+	JSON bool
+
+	Rotation Rotation
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Level:  slog.LevelInfo,
+		Dir:    "output",
+		Stdout: true,
+	}
+}
+
+type Sink struct {
+	Logger *slog.Logger
+
+	Dir string
+
+	closers []io.Closer
+}
+
+func (c *Config) Open(dir string) (*Sink, error) {
+	sink := &Sink{Dir: dir}
+
+	var handlers []slog.Handler
+	if c.Handler != nil {
+		handlers = append(handlers, c.Handler)
+	}
+	if c.Stdout {
+		handlers = append(handlers, c.newHandler(os.Stdout))
+	}
+	if dir != "" {
+		file, err := OpenRotatingFile(filepath.Join(dir, MessagesFile), c.Rotation.MaxSizeBytes, c.Rotation.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		sink.closers = append(sink.closers, file)
+		handlers = append(handlers, c.newHandler(file))
+	}
+
+	switch len(handlers) {
+	case 0:
+		sink.Logger = slog.New(discardHandler{})
+	case 1:
+		sink.Logger = slog.New(handlers[0])
+	default:
+		sink.Logger = slog.New(fanoutHandler(handlers))
+	}
+	return sink, nil
+}
+
+func (c *Config) newHandler(w io.Writer) slog.Handler {
+	opts := &slog.HandlerOptions{Level: c.Level}
+	if c.JSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+func (s *Sink) Close() error {
+	var errs []error
+	for _, c := range s.closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.closers = nil
+	return errors.Join(errs...)
+}
+
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, record.Level) {
+			if err := h.Handle(ctx, record.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
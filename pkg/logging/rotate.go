@@ -0,0 +1,176 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type RotatingFile struct {
+	mu sync.Mutex
+
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	file *os.File
+	size int64
+
+	refs         int
+	rotateFailed bool
+}
+
+var (
+	openMu sync.Mutex
+	opened = make(map[string]*RotatingFile)
+)
+
+func OpenRotatingFile(path string, maxBytes int64, maxBackups int) (*RotatingFile, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve log file %s: %w", path, err)
+	}
+
+	openMu.Lock()
+	defer openMu.Unlock()
+
+	if r, ok := opened[absPath]; ok {
+		if r.maxBytes != maxBytes || r.maxBackups != maxBackups {
+			return nil, fmt.Errorf("log file %s is already open with max size %d bytes and %d backups, cannot reopen with %d bytes and %d backups", absPath, r.maxBytes, r.maxBackups, maxBytes, maxBackups)
+		}
+		r.refs++
+		return r, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory %s: %w", filepath.Dir(absPath), err)
+	}
+
+	r := &RotatingFile{
+		path:       absPath,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+		refs:       1,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	opened[absPath] = r
+	return r, nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	n, rotateErr, err := r.write(p)
+	if rotateErr != nil {
+		slog.Default().Warn("log rotation failed, continuing in the current file", "component", "logging", "path", r.path, "error", rotateErr)
+	}
+	return n, err
+}
+
+func (r *RotatingFile) write(p []byte) (n int, rotateErr, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if r.refs == 0 {
+			return 0, nil, os.ErrClosed
+		}
+		if err := r.open(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if r.maxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			if r.file == nil {
+				if openErr := r.open(); openErr != nil {
+					return 0, nil, errors.Join(err, openErr)
+				}
+			}
+			if !r.rotateFailed {
+				rotateErr = err
+			}
+			r.rotateFailed = true
+		} else {
+			r.rotateFailed = false
+		}
+	}
+
+	n, err = r.file.Write(p)
+	r.size += int64(n)
+	return n, rotateErr, err
+}
+
+func (r *RotatingFile) Close() error {
+	openMu.Lock()
+	defer openMu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.refs == 0 {
+		return nil
+	}
+	r.refs--
+	if r.refs > 0 {
+		return nil
+	}
+	delete(opened, r.path)
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", r.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file %s: %w", r.path, err)
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file %s: %w", r.path, err)
+	}
+	r.file = nil
+
+	if r.maxBackups <= 0 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove log file %s: %w", r.path, err)
+		}
+		return r.open()
+	}
+
+	os.Remove(backupName(r.path, r.maxBackups))
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		src := backupName(r.path, i)
+		if _, err := os.Stat(src); err == nil {
+			if err := os.Rename(src, backupName(r.path, i+1)); err != nil {
+				return fmt.Errorf("failed to rotate log file %s: %w", src, err)
+			}
+		}
+	}
+	if err := os.Rename(r.path, backupName(r.path, 1)); err != nil {
+		return fmt.Errorf("failed to rotate log file %s: %w", r.path, err)
+	}
+	return r.open()
+}
+
+func backupName(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFileSharedPerPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.log")
+	a, err := OpenRotatingFile(path, 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := OpenRotatingFile(path, 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Fatal("second open of the same path returned a different instance")
+	}
+	if _, err := OpenRotatingFile(path, 200, 2); err == nil {
+		t.Fatal("open with different rotation settings succeeded")
+	}
+
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Write([]byte("still open\n")); err != nil {
+		t.Fatalf("write after closing one reference: %v", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Write([]byte("closed\n")); err == nil {
+		t.Fatal("write after closing every reference succeeded")
+	}
+}
+
+func TestRotatingFileRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.log")
+	r, err := OpenRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for _, line := range []string{"0123456789", "abc", "def"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q): %v", line, err)
+		}
+	}
+	assertFile(t, path+".1", "0123456789")
+	assertFile(t, path, "abcdef")
+}
+
+func TestRotatingFileKeepsWritingWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.log")
+	r, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if _, err := r.Write([]byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{path + ".1", path + ".2"} {
+		if err := os.MkdirAll(filepath.Join(dir, "blocker"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, line := range []string{"abc", "def"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q) after failed rotation: %v", line, err)
+		}
+	}
+	assertFile(t, path, "0123456789abcdef")
+}
+
+func assertFile(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("%s = %q, want %q", filepath.Base(path), data, want)
+	}
+}
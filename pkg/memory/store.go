@@ -0,0 +1,276 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Embedder interface {
+	Embed(ctx context.Context, inputs []string) ([][]float32, error)
+}
+
+type ModelEmbedder interface {
+	Embedder
+
+	EmbeddingModel() (string, error)
+}
+
+type Entry struct {
+	ID        string            `json:"id"`
+	Model     string            `json:"model,omitempty"`
+	Text      string            `json:"text"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	Vector    []float32         `json:"vector"`
+}
+
+type Result struct {
+	Entry
+	Score float64 `json:"score"`
+}
+
+type Store struct {
+	path string
+
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+var (
+	openMu sync.Mutex
+	opened = make(map[string]*Store)
+)
+
+func Open(path string) (*Store, error) {
+	if path == "" {
+		return &Store{}, nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	openMu.Lock()
+	defer openMu.Unlock()
+
+	if s, ok := opened[absPath]; ok {
+		return s, nil
+	}
+
+	s := &Store{path: absPath}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	opened[absPath] = s
+	return s, nil
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取记忆文件失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return fmt.Errorf("解析记忆文件 %s 失败: %w", s.path, err)
+	}
+	for i := range s.entries {
+		normalize(s.entries[i].Vector)
+	}
+	return nil
+}
+
+func (s *Store) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建记忆目录失败: %w", err)
+	}
+
+	data, err := json.Marshal(s.entries)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("写入记忆文件失败: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入记忆文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入记忆文件失败: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *Store) Add(model, text string, vector []float32, metadata map[string]string) (Entry, error) {
+	if len(vector) == 0 {
+		return Entry{}, fmt.Errorf("向量不能为空")
+	}
+
+	entry := Entry{
+		ID:        uuid.New().String(),
+		Model:     model,
+		Text:      text,
+		Metadata:  metadata,
+		CreatedAt: time.Now(),
+		Vector:    append([]float32(nil), vector...),
+	}
+	normalize(entry.Vector)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, entry)
+	if err := s.saveLocked(); err != nil {
+		s.entries = s.entries[:len(s.entries)-1]
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, entry := range s.entries {
+		if entry.ID == id {
+			removed := s.entries
+			s.entries = append(append([]Entry(nil), s.entries[:i]...), s.entries[i+1:]...)
+			if err := s.saveLocked(); err != nil {
+				s.entries = removed
+				return err
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("记忆 '%s' 不存在", id)
+}
+
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
+
+func (s *Store) Entries() []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Entry(nil), s.entries...)
+}
+
+func (s *Store) Search(model string, vector []float32, k int, minScore float64) []Result {
+	query := append([]float32(nil), vector...)
+	normalize(query)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []Result
+	skipped := 0
+	for _, entry := range s.entries {
+		if entry.Model != "" && model != "" && entry.Model != model || len(entry.Vector) != len(query) {
+			skipped++
+			continue
+		}
+		score := dot(entry.Vector, query)
+		if score < minScore {
+			continue
+		}
+		results = append(results, Result{Entry: entry, Score: score})
+	}
+
+	if skipped > 0 {
+		slog.Default().Warn("跳过由其他 embedding 模型生成的记忆", "component", "memory", "path", s.path, "model", model, "skipped", skipped)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+type Memory struct {
+	Store    *Store
+	Embedder Embedder
+}
+
+func (m *Memory) Remember(ctx context.Context, text string, metadata map[string]string) (Entry, error) {
+	model, err := EmbeddingModel(m.Embedder)
+	if err != nil {
+		return Entry{}, fmt.Errorf("生成向量失败: %w", err)
+	}
+	vectors, err := m.Embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return Entry{}, fmt.Errorf("生成向量失败: %w", err)
+	}
+	return m.Store.Add(model, text, vectors[0], metadata)
+}
+
+func (m *Memory) Recall(ctx context.Context, query string, k int, minScore float64) ([]Result, error) {
+	model, err := EmbeddingModel(m.Embedder)
+	if err != nil {
+		return nil, fmt.Errorf("生成向量失败: %w", err)
+	}
+	vectors, err := m.Embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("生成向量失败: %w", err)
+	}
+	return m.Store.Search(model, vectors[0], k, minScore), nil
+}
+
+func EmbeddingModel(embedder Embedder) (string, error) {
+	if me, ok := embedder.(ModelEmbedder); ok {
+		return me.EmbeddingModel()
+	}
+	return "", nil
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type RememberTool struct {
+	memory *Memory
+}
+
+func NewRememberTool(m *Memory) *RememberTool {
+	return &RememberTool{memory: m}
+}
+
+func (t *RememberTool) Name() string {
+	return "Remember"
+}
+
+func (t *RememberTool) Description() string {
+	return `长期记忆 - 保存一条以后可能用到的事实、偏好或结论，跨运行保留。
+每次只保存一条简洁、独立、无需上下文即可理解的信息。`
+}
+
+func (t *RememberTool) Parameters() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"fact": {
+				"type": "string",
+				"description": "要记住的信息，应当完整、独立。"
+			},
+			"tags": {
+				"type": "array",
+				"items": {"type": "string"},
+				"description": "可选的标签，便于之后检索。"
+			}
+		},
+		"required": ["fact"]
+	}`)
+}
+
+func (t *RememberTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	fact, ok := args["fact"].(string)
+	if !ok || strings.TrimSpace(fact) == "" {
+		return "", fmt.Errorf("无效的参数：'fact' 必须是一个非空字符串")
+	}
+
+	var metadata map[string]string
+	if rawTags, ok := args["tags"].([]interface{}); ok && len(rawTags) > 0 {
+		tags := make([]string, 0, len(rawTags))
+		for _, tag := range rawTags {
+			if s, ok := tag.(string); ok && s != "" {
+				tags = append(tags, s)
+			}
+		}
+		metadata = map[string]string{"tags": strings.Join(tags, ",")}
+	}
+
+	entry, err := t.memory.Remember(ctx, strings.TrimSpace(fact), metadata)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("已记住 (id: %s)。", entry.ID), nil
+}
+
+type RecallTool struct {
+	memory   *Memory
+	topK     int
+	minScore float64
+}
+
+func NewRecallTool(m *Memory, topK int, minScore float64) *RecallTool {
+	if topK <= 0 {
+		topK = 5
+	}
+	return &RecallTool{memory: m, topK: topK, minScore: minScore}
+}
+
+func (t *RecallTool) Name() string {
+	return "Recall"
+}
+
+func (t *RecallTool) Description() string {
+	return `长期记忆检索 - 按语义相似度查找之前保存的信息。
+在回答依赖用户偏好、历史结论或之前运行中得到的事实时使用。`
+}
+
+func (t *RecallTool) Parameters() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"query": {
+				"type": "string",
+				"description": "要查找的内容的描述。"
+			},
+			"limit": {
+				"type": "integer",
+				"description": "最多返回的条数。"
+			}
+		},
+		"required": ["query"]
+	}`)
+}
+
+func (t *RecallTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("无效的参数：'query' 必须是一个非空字符串")
+	}
+
+	limit := t.topK
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		limit = int(n)
+	}
+
+	results, err := t.memory.Recall(ctx, query, limit, t.minScore)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "没有找到相关的记忆。", nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "找到 %d 条相关记忆:\n", len(results))
+	for i, r := range results {
+		fmt.Fprintf(&sb, "%d. [%.2f] %s", i+1, r.Score, r.Text)
+		if tags := r.Metadata["tags"]; tags != "" {
+			fmt.Fprintf(&sb, " (标签: %s)", tags)
+		}
+		fmt.Fprintf(&sb, " — %s\n", r.CreatedAt.Format("2006-01-02"))
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
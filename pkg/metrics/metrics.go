@@ -0,0 +1,59 @@
+package metrics
+
+import "sync/atomic"
+
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+type Counter interface {
+	Add(value float64, labelValues ...string)
+}
+
+type Histogram interface {
+	Observe(value float64, labelValues ...string)
+}
+
+type Gauge interface {
+	Set(value float64, labelValues ...string)
+
+	Add(value float64, labelValues ...string)
+}
+
+type Provider interface {
+	Counter(name, help string, labelNames ...string) Counter
+
+	Histogram(name, help string, buckets []float64, labelNames ...string) Histogram
+
+	Gauge(name, help string, labelNames ...string) Gauge
+}
+
+type NoOp struct{}
+
+func (NoOp) Counter(string, string, ...string) Counter                { return noop{} }
+func (NoOp) Histogram(string, string, []float64, ...string) Histogram { return noop{} }
+func (NoOp) Gauge(string, string, ...string) Gauge                    { return noop{} }
+
+type noop struct{}
+
+func (noop) Add(float64, ...string)     {}
+func (noop) Observe(float64, ...string) {}
+func (noop) Set(float64, ...string)     {}
+
+type holder struct {
+	provider Provider
+}
+
+var defaultProvider atomic.Pointer[holder]
+
+func SetDefault(p Provider) {
+	if p == nil {
+		p = NoOp{}
+	}
+	defaultProvider.Store(&holder{provider: p})
+}
+
+func Default() Provider {
+	if h := defaultProvider.Load(); h != nil {
+		return h.provider
+	}
+	return NoOp{}
+}
@@ -0,0 +1,215 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+type family struct {
+	mu sync.Mutex
+
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	buckets    []float64
+
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+
+	value float64
+
+	bucketCounts []uint64
+	count        uint64
+	sum          float64
+}
+
+func (r *Registry) Counter(name, help string, labelNames ...string) Counter {
+	return r.family(name, help, "counter", nil, labelNames)
+}
+
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return r.family(name, help, "histogram", sorted, labelNames)
+}
+
+func (r *Registry) Gauge(name, help string, labelNames ...string) Gauge {
+	return r.family(name, help, "gauge", nil, labelNames)
+}
+
+func (r *Registry) family(name, help, kind string, buckets []float64, labelNames []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, ok := r.families[name]; ok {
+		if f.kind != kind {
+			panic(fmt.Sprintf("metric %s already registered as %s", name, f.kind))
+		}
+		return f
+	}
+
+	f := &family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*series),
+	}
+	r.families[name] = f
+	return f
+}
+
+func (f *family) get(labelValues []string) *series {
+	values := make([]string, len(f.labelNames))
+	copy(values, labelValues)
+	key := strings.Join(values, "\xff")
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: values}
+		if f.kind == "histogram" {
+			s.bucketCounts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) Add(value float64, labelValues ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.get(labelValues).value += value
+}
+
+func (f *family) Set(value float64, labelValues ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.get(labelValues).value = value
+}
+
+func (f *family) Observe(value float64, labelValues ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s := f.get(labelValues)
+	for i, upper := range f.buckets {
+		if value <= upper {
+			s.bucketCounts[i]++
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := make([]*family, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		families = append(families, r.families[name])
+	}
+	r.mu.Unlock()
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WritePrometheus(w)
+	})
+}
+
+func (f *family) write(b *strings.Builder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := f.series[k]
+		switch f.kind {
+		case "histogram":
+			for i, upper := range f.buckets {
+				fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labels(s.labelValues, "le", formatFloat(upper)), s.bucketCounts[i])
+			}
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labels(s.labelValues, "le", "+Inf"), s.count)
+			fmt.Fprintf(b, "%s_sum%s %s\n", f.name, f.labels(s.labelValues), formatFloat(s.sum))
+			fmt.Fprintf(b, "%s_count%s %d\n", f.name, f.labels(s.labelValues), s.count)
+		default:
+			fmt.Fprintf(b, "%s%s %s\n", f.name, f.labels(s.labelValues), formatFloat(s.value))
+		}
+	}
+}
+
+func (f *family) labels(values []string, extra ...string) string {
+	var pairs []string
+	for i, name := range f.labelNames {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabel(values[i])))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabel(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
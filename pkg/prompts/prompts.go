@@ -0,0 +1,230 @@
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates
+var builtinTemplates embed.FS
+
+const (
+	DefaultSystemPrompt = "default_system_prompt"
+	SystemPrompt        = "system_prompt"
+	ToolsSection        = "tools_section"
+	BackgroundSection   = "background_section"
+	FormatSection       = "format_section"
+	ResponseSchema      = "response_schema"
+	ParseError          = "parse_error"
+	WaitWarning         = "wait_warning"
+	FinishPending       = "finish_pending"
+	JobsCompleted       = "jobs_completed"
+	BackgroundStarted   = "background_started"
+	BackgroundResult    = "background_result"
+	BackgroundError     = "background_error"
+	ToolFailed          = "tool_failed"
+	ToolNotFound        = "tool_not_found"
+	MaxIterations       = "max_iterations"
+	RetrievedHistory    = "retrieved_history"
+	CollapsedResult     = "collapsed_result"
+	SummarizeHistory    = "summarize_history"
+	HistorySummary      = "history_summary"
+)
+
+const (
+	Chinese = "zh"
+	English = "en"
+)
+
+const templateExt = ".tmpl"
+
+type ToolData struct {
+	Name        string
+	Description string
+	Parameters  string
+}
+
+type SystemData struct {
+	SystemPrompt string
+	Tools        []ToolData
+}
+
+type ErrorData struct {
+	Error string
+}
+
+type ToolErrorData struct {
+	Tool      string
+	Error     string
+	Available []string
+}
+
+type JobData struct {
+	Tool   string
+	JobID  string
+	Args   string
+	Result string
+	Error  string
+}
+
+type HistoryEntry struct {
+	Role    string
+	Type    string
+	Content string
+}
+
+type HistoryData struct {
+	Entries []HistoryEntry
+}
+
+type CollapsedData struct {
+	Preview string
+	Omitted int
+}
+
+type SummaryData struct {
+	Summary string
+	Count   int
+}
+
+var funcs = template.FuncMap{
+	"join": strings.Join,
+}
+
+type Set struct {
+	language string
+	tmpl     *template.Template
+}
+
+func Languages() []string {
+	entries, err := builtinTemplates.ReadDir("templates")
+	if err != nil {
+		return nil
+	}
+	var langs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			langs = append(langs, e.Name())
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+func Load(language string) (*Set, error) {
+	if language == "" {
+		language = Chinese
+	}
+
+	dir := path.Join("templates", language)
+	entries, err := fs.ReadDir(builtinTemplates, dir)
+	if err != nil {
+		return nil, fmt.Errorf("unknown prompt language '%s' (available: %s)", language, strings.Join(Languages(), ", "))
+	}
+
+	s := &Set{
+		language: language,
+		tmpl:     template.New(language).Funcs(funcs),
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), templateExt) {
+			continue
+		}
+		content, err := fs.ReadFile(builtinTemplates, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read builtin prompt template %s: %w", e.Name(), err)
+		}
+		if err := s.parse(strings.TrimSuffix(e.Name(), templateExt), string(content)); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func MustLoad(language string) *Set {
+	s, err := Load(language)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func Default() *Set {
+	return MustLoad(Chinese)
+}
+
+func (s *Set) Language() string {
+	return s.language
+}
+
+func (s *Set) Names() []string {
+	var names []string
+	for _, t := range s.tmpl.Templates() {
+		if t.Name() != s.language {
+			names = append(names, t.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Set) Clone() (*Set, error) {
+	cloned, err := s.tmpl.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone prompt set: %w", err)
+	}
+	return &Set{language: s.language, tmpl: cloned}, nil
+}
+
+func (s *Set) Override(name, text string) error {
+	if s.tmpl.Lookup(name) == nil {
+		return fmt.Errorf("unknown prompt template '%s'", name)
+	}
+	return s.parse(name, text)
+}
+
+func (s *Set) OverrideFromFile(name, filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt template file %s: %w", filePath, err)
+	}
+	return s.Override(name, string(content))
+}
+
+func (s *Set) OverrideFromDir(dir string) error {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+templateExt))
+	if err != nil {
+		return fmt.Errorf("failed to list prompt templates in %s: %w", dir, err)
+	}
+	for _, m := range matches {
+		name := strings.TrimSuffix(filepath.Base(m), templateExt)
+		if err := s.OverrideFromFile(name, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Set) Render(name string, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := s.tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template '%s': %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+func (s *Set) parse(name, text string) error {
+	text = strings.TrimSuffix(text, "\n")
+	if _, err := s.tmpl.New(name).Parse(text); err != nil {
+		return fmt.Errorf("failed to parse prompt template '%s': %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"sync"
+
+	"hivemind-go/pkg/transcript"
+)
+
+type Event struct {
+	Seq int `json:"seq"`
+
+	transcript.Record
+}
+
+const subscriberBuffer = 256
+
+const eventLagged = "lagged"
+
+type eventLog struct {
+	mu sync.Mutex
+
+	events  []Event
+	start   int
+	limit   int
+	nextSeq int
+	closed  bool
+
+	subscribers map[chan Event]struct{}
+}
+
+func newEventLog(limit int) *eventLog {
+	return &eventLog{
+		limit:       limit,
+		nextSeq:     1,
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+func (l *eventLog) publish(rec transcript.Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return
+	}
+
+	event := Event{Seq: l.nextSeq, Record: rec}
+	l.nextSeq++
+
+	if l.limit > 0 && len(l.events) == l.limit {
+		l.events[l.start] = event
+		l.start = (l.start + 1) % l.limit
+	} else {
+		l.events = append(l.events, event)
+	}
+
+	for ch := range l.subscribers {
+		if len(ch) < subscriberBuffer {
+			ch <- event
+			continue
+		}
+		ch <- Event{Record: transcript.Record{Type: eventLagged}}
+		delete(l.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (l *eventLog) subscribe(after int) ([]Event, chan Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var replay []Event
+	for i := range l.events {
+		event := l.events[(l.start+i)%len(l.events)]
+		if event.Seq > after {
+			replay = append(replay, event)
+		}
+	}
+
+	if l.closed {
+		return replay, nil
+	}
+
+	ch := make(chan Event, subscriberBuffer+1)
+	l.subscribers[ch] = struct{}{}
+	return replay, ch
+}
+
+func (l *eventLog) unsubscribe(ch chan Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.subscribers[ch]; ok {
+		delete(l.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (l *eventLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return
+	}
+	l.closed = true
+	for ch := range l.subscribers {
+		delete(l.subscribers, ch)
+		close(ch)
+	}
+}
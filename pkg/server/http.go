@@ -0,0 +1,154 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type Server struct {
+	manager *Manager
+	mux     *http.ServeMux
+	logger  *slog.Logger
+}
+
+type Option func(*Server)
+
+func WithMetricsHandler(h http.Handler) Option {
+	return func(s *Server) {
+		s.mux.Handle("GET /metrics", h)
+	}
+}
+
+func New(manager *Manager, opts ...Option) *Server {
+	s := &Server{
+		manager: manager,
+		mux:     http.NewServeMux(),
+		logger:  slog.Default().With("component", "http"),
+	}
+
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	s.mux.HandleFunc("GET /v1/agents", s.handleListAgents)
+	s.mux.HandleFunc("POST /v1/runs", s.handleCreateRun)
+	s.mux.HandleFunc("GET /v1/runs", s.handleListRuns)
+	s.mux.HandleFunc("GET /v1/runs/{id}", s.handleGetRun)
+	s.mux.HandleFunc("GET /v1/runs/{id}/transcript", s.handleTranscript)
+	s.mux.HandleFunc("GET /v1/runs/{id}/events", s.handleEvents)
+	s.mux.HandleFunc("POST /v1/runs/{id}/cancel", s.handleCancelRun)
+
+	s.mux.HandleFunc("GET /v1/models", s.handleListModels)
+	s.mux.HandleFunc("POST /v1/chat/completions", s.handleChatCompletions)
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+type createRunRequest struct {
+	Agent   string `json:"agent"`
+	Input   string `json:"input"`
+	NoCache bool   `json:"no_cache"`
+}
+
+func runOptions(r *http.Request, noCache bool) []RunOption {
+	if noCache || strings.Contains(strings.ToLower(r.Header.Get("Cache-Control")), "no-cache") {
+		return []RunOption{WithCacheBypass()}
+	}
+	return nil
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleListAgents(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"agents": s.manager.Agents()})
+}
+
+func (s *Server) handleCreateRun(w http.ResponseWriter, r *http.Request) {
+	var req createRunRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if strings.TrimSpace(req.Agent) == "" || strings.TrimSpace(req.Input) == "" {
+		writeError(w, http.StatusBadRequest, "both 'agent' and 'input' are required")
+		return
+	}
+
+	info, err := s.manager.Start(req.Agent, req.Input, runOptions(r, req.NoCache)...)
+	if err != nil {
+		s.writeManagerError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/runs/"+info.ID)
+	writeJSON(w, http.StatusAccepted, info)
+}
+
+func (s *Server) handleListRuns(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	runs := s.manager.List(query.Get("agent"), RunStatus(query.Get("status")))
+	writeJSON(w, http.StatusOK, map[string]interface{}{"runs": runs})
+}
+
+func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	info, err := s.manager.Get(r.PathValue("id"))
+	if err != nil {
+		s.writeManagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) handleTranscript(w http.ResponseWriter, r *http.Request) {
+	messages, err := s.manager.Transcript(r.PathValue("id"))
+	if err != nil {
+		s.writeManagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"messages": messages})
+}
+
+func (s *Server) handleCancelRun(w http.ResponseWriter, r *http.Request) {
+	info, err := s.manager.Cancel(r.PathValue("id"))
+	if err != nil {
+		s.writeManagerError(w, err)
+		return
+	}
+	status := http.StatusOK
+	if info.Status == StatusCancelling {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, info)
+}
+
+func (s *Server) writeManagerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownAgent), errors.Is(err, ErrRunNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrQueueFull):
+		writeError(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, ErrShuttingDown):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		s.logger.Error("request failed", "error", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,433 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hivemind-go/pkg/agent"
+	"hivemind-go/pkg/builder"
+	"hivemind-go/pkg/llmclient"
+	"hivemind-go/pkg/tools"
+	"hivemind-go/pkg/transcript"
+	"hivemind-go/pkg/types"
+)
+
+type RunStatus string
+
+const (
+	StatusQueued     RunStatus = "queued"
+	StatusRunning    RunStatus = "running"
+	StatusCancelling RunStatus = "cancelling"
+	StatusSucceeded  RunStatus = "succeeded"
+	StatusFailed     RunStatus = "failed"
+	StatusCanceled   RunStatus = "canceled"
+)
+
+func (s RunStatus) Finished() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCanceled
+}
+
+var (
+	ErrUnknownAgent = errors.New("unknown agent")
+	ErrRunNotFound  = errors.New("run not found")
+	ErrQueueFull    = errors.New("run queue is full")
+	ErrShuttingDown = errors.New("run manager is shutting down")
+)
+
+type AgentFactory interface {
+	Agents() []string
+
+	Build(name string) (*agent.Agent, error)
+}
+
+type DefinitionsFactory struct {
+	Definitions *builder.Definitions
+	Registry    *builder.Registry
+	LLMClient   *llmclient.LLMClient
+}
+
+func (f *DefinitionsFactory) Agents() []string {
+	return f.Definitions.Names()
+}
+
+func (f *DefinitionsFactory) Build(name string) (*agent.Agent, error) {
+	registry := f.Registry
+	if registry == nil {
+		registry = builder.DefaultRegistry
+	}
+	config, err := f.Definitions.AgentConfig(name, registry)
+	if err != nil {
+		return nil, err
+	}
+	return registry.BuildAgent(config, f.LLMClient, tools.NewContext())
+}
+
+type RunInfo struct {
+	ID         string     `json:"id"`
+	Agent      string     `json:"agent"`
+	Input      string     `json:"input"`
+	Status     RunStatus  `json:"status"`
+	Result     string     `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Incomplete bool       `json:"incomplete,omitempty"`
+	NoCache    bool       `json:"no_cache,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+type RunOption func(*RunInfo)
+
+func WithCacheBypass() RunOption {
+	return func(info *RunInfo) {
+		info.NoCache = true
+	}
+}
+
+type run struct {
+	info RunInfo
+
+	agent  *agent.Agent
+	events *eventLog
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type ManagerConfig struct {
+	MaxConcurrent int
+
+	MaxQueued int
+
+	MaxRetained int
+
+	RunTimeout time.Duration
+
+	MaxEvents int
+}
+
+type Manager struct {
+	factory AgentFactory
+	config  ManagerConfig
+	logger  *slog.Logger
+
+	slots chan struct{}
+
+	mu       sync.Mutex
+	runs     map[string]*run
+	order    []string
+	queued   int
+	closing  bool
+	inflight sync.WaitGroup
+
+	baseCtx    context.Context
+	cancelBase context.CancelFunc
+}
+
+func NewManager(factory AgentFactory, config ManagerConfig) *Manager {
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = 4
+	}
+	if config.MaxRetained <= 0 {
+		config.MaxRetained = 1000
+	}
+	if config.MaxEvents <= 0 {
+		config.MaxEvents = 10000
+	}
+
+	baseCtx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		factory:    factory,
+		config:     config,
+		logger:     slog.Default().With("component", "run_manager"),
+		slots:      make(chan struct{}, config.MaxConcurrent),
+		runs:       make(map[string]*run),
+		baseCtx:    baseCtx,
+		cancelBase: cancel,
+	}
+}
+
+func (m *Manager) Agents() []string {
+	return m.factory.Agents()
+}
+
+func (m *Manager) Start(agentName, input string, opts ...RunOption) (RunInfo, error) {
+	if !m.knownAgent(agentName) {
+		return RunInfo{}, fmt.Errorf("%w: %s", ErrUnknownAgent, agentName)
+	}
+
+	m.mu.Lock()
+	if m.closing {
+		m.mu.Unlock()
+		return RunInfo{}, ErrShuttingDown
+	}
+	if m.config.MaxQueued > 0 && m.queued >= m.config.MaxQueued {
+		m.mu.Unlock()
+		return RunInfo{}, ErrQueueFull
+	}
+	m.queued++
+	m.mu.Unlock()
+
+	instance, err := m.factory.Build(agentName)
+	if err != nil {
+		m.mu.Lock()
+		m.queued--
+		m.mu.Unlock()
+		return RunInfo{}, fmt.Errorf("failed to build agent %s: %w", agentName, err)
+	}
+
+	runCtx, cancel := context.WithCancel(m.baseCtx)
+	if m.config.RunTimeout > 0 {
+		var timeoutCancel context.CancelFunc
+		runCtx, timeoutCancel = context.WithTimeout(runCtx, m.config.RunTimeout)
+		parentCancel := cancel
+		cancel = func() {
+			timeoutCancel()
+			parentCancel()
+		}
+	}
+
+	r := &run{
+		info: RunInfo{
+			ID:        uuid.New().String(),
+			Agent:     agentName,
+			Input:     input,
+			Status:    StatusQueued,
+			CreatedAt: time.Now(),
+		},
+		agent:  instance,
+		events: newEventLog(m.config.MaxEvents),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&r.info)
+	}
+
+	m.mu.Lock()
+	m.runs[r.info.ID] = r
+	m.order = append(m.order, r.info.ID)
+	m.pruneLocked()
+	info := r.info
+	m.inflight.Add(1)
+	m.mu.Unlock()
+
+	go m.execute(runCtx, r)
+
+	return info, nil
+}
+
+func (m *Manager) execute(ctx context.Context, r *run) {
+	defer m.inflight.Done()
+	defer close(r.done)
+	defer r.events.close()
+	defer r.agent.Close()
+	defer r.cancel()
+
+	select {
+	case m.slots <- struct{}{}:
+	case <-ctx.Done():
+		m.mu.Lock()
+		m.queued--
+		m.finishLocked(r, "", ctx.Err())
+		m.mu.Unlock()
+		return
+	}
+	defer func() { <-m.slots }()
+
+	m.mu.Lock()
+	m.queued--
+	started := time.Now()
+	r.info.StartedAt = &started
+	if r.info.Status != StatusCancelling {
+		r.info.Status = StatusRunning
+	}
+	m.mu.Unlock()
+
+	m.logger.Info("run started", "run_id", r.info.ID, "agent", r.info.Agent)
+	if r.info.NoCache {
+		ctx = llmclient.WithCacheBypass(ctx)
+	}
+	incomplete := false
+	result, err := r.agent.Run(agent.ContextWithEventHandler(ctx, func(event transcript.Record) {
+		if event.Type == "run_end" && event.ParentRunID == "" && event.Status == agent.StatusMaxIterations {
+			incomplete = true
+		}
+		r.events.publish(event)
+	}), r.info.Input)
+
+	m.mu.Lock()
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	m.finishLocked(r, result, err)
+	r.info.Incomplete = err == nil && incomplete
+	status := r.info.Status
+	m.mu.Unlock()
+
+	m.logger.Info("run finished", "run_id", r.info.ID, "agent", r.info.Agent, "status", status)
+}
+
+func (m *Manager) finishLocked(r *run, result string, err error) {
+	finished := time.Now()
+	r.info.FinishedAt = &finished
+	r.info.Result = result
+
+	switch {
+	case err == nil:
+		r.info.Status = StatusSucceeded
+	case errors.Is(err, context.Canceled):
+		r.info.Status = StatusCanceled
+		r.info.Error = err.Error()
+	default:
+		r.info.Status = StatusFailed
+		r.info.Error = err.Error()
+	}
+}
+
+func (m *Manager) pruneLocked() {
+	for len(m.order) > m.config.MaxRetained {
+		pruned := false
+		for i, id := range m.order {
+			if r := m.runs[id]; r != nil && r.info.Status.Finished() {
+				delete(m.runs, id)
+				m.order = append(m.order[:i], m.order[i+1:]...)
+				pruned = true
+				break
+			}
+		}
+		if !pruned {
+			return
+		}
+	}
+}
+
+func (m *Manager) Get(id string) (RunInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.runs[id]
+	if !ok {
+		return RunInfo{}, ErrRunNotFound
+	}
+	return r.info, nil
+}
+
+func (m *Manager) List(agentName string, status RunStatus) []RunInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]RunInfo, 0, len(m.order))
+	for _, id := range m.order {
+		r := m.runs[id]
+		if agentName != "" && r.info.Agent != agentName {
+			continue
+		}
+		if status != "" && r.info.Status != status {
+			continue
+		}
+		infos = append(infos, r.info)
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].CreatedAt.After(infos[j].CreatedAt)
+	})
+	return infos
+}
+
+func (m *Manager) Transcript(id string) ([]types.Message, error) {
+	m.mu.Lock()
+	r, ok := m.runs[id]
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrRunNotFound
+	}
+	return r.agent.Messages(), nil
+}
+
+func (m *Manager) Events(id string, after int) ([]Event, <-chan Event, func(), error) {
+	m.mu.Lock()
+	r, ok := m.runs[id]
+	m.mu.Unlock()
+	if !ok {
+		return nil, nil, nil, ErrRunNotFound
+	}
+
+	replay, ch := r.events.subscribe(after)
+	if ch == nil {
+		return replay, nil, func() {}, nil
+	}
+	return replay, ch, func() { r.events.unsubscribe(ch) }, nil
+}
+
+func (m *Manager) Cancel(id string) (RunInfo, error) {
+	m.mu.Lock()
+	r, ok := m.runs[id]
+	m.mu.Unlock()
+	if !ok {
+		return RunInfo{}, ErrRunNotFound
+	}
+
+	m.mu.Lock()
+	if !r.info.Status.Finished() {
+		r.info.Status = StatusCancelling
+	}
+	info := r.info
+	m.mu.Unlock()
+
+	r.cancel()
+	return info, nil
+}
+
+func (m *Manager) Wait(ctx context.Context, id string) (RunInfo, error) {
+	m.mu.Lock()
+	r, ok := m.runs[id]
+	m.mu.Unlock()
+	if !ok {
+		return RunInfo{}, ErrRunNotFound
+	}
+
+	select {
+	case <-r.done:
+		return m.Get(id)
+	case <-ctx.Done():
+		return RunInfo{}, ctx.Err()
+	}
+}
+
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closing = true
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.inflight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		m.cancelBase()
+		<-done
+		err = ctx.Err()
+	}
+	m.cancelBase()
+	return err
+}
+
+func (m *Manager) knownAgent(name string) bool {
+	for _, n := range m.factory.Agents() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type openAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
+
+func (s *Server) handleListModels(w http.ResponseWriter, _ *http.Request) {
+	models := make([]openai.Model, 0)
+	for _, name := range s.manager.Agents() {
+		models = append(models, openai.Model{ID: name, Object: "model", OwnedBy: "hivemind", Root: name})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"object": "list", "data": models})
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req openai.ChatCompletionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<20)).Decode(&req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "", "invalid request body: "+err.Error())
+		return
+	}
+	if req.Model == "" {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "", "'model' is required")
+		return
+	}
+	input := chatInput(req.Messages)
+	if input == "" {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "", "'messages' must contain at least one non-empty message")
+		return
+	}
+
+	info, err := s.manager.Start(req.Model, input, runOptions(r, false)...)
+	if err != nil {
+		s.writeOpenAIManagerError(w, err)
+		return
+	}
+	w.Header().Set("X-Hivemind-Run-Id", info.ID)
+
+	replay, events, unsubscribe, err := s.manager.Events(info.ID, 0)
+	if err != nil {
+		s.writeOpenAIManagerError(w, err)
+		return
+	}
+	defer func() { unsubscribe() }()
+
+	completion := &chatCompletion{
+		id:      "chatcmpl-" + info.ID,
+		created: info.CreatedAt.Unix(),
+		model:   req.Model,
+	}
+	if req.Stream {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			s.manager.Cancel(info.ID)
+			writeOpenAIError(w, http.StatusInternalServerError, "server_error", "", "streaming is not supported")
+			return
+		}
+		completion.stream = w
+		completion.flusher = flusher
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		completion.writeChunk(openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant}, "")
+	}
+
+	var keepalive <-chan time.Time
+	if req.Stream {
+		heartbeat := time.NewTicker(sseHeartbeat)
+		defer heartbeat.Stop()
+		keepalive = heartbeat.C
+	}
+
+	for _, event := range replay {
+		completion.observe(event)
+	}
+	for events != nil {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if event.Type == eventLagged {
+				unsubscribe()
+				if replay, events, unsubscribe, err = s.manager.Events(info.ID, completion.seq); err != nil {
+					events, unsubscribe = nil, func() {}
+				}
+				for _, event := range replay {
+					completion.observe(event)
+				}
+				continue
+			}
+			completion.observe(event)
+		case <-keepalive:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				s.manager.Cancel(info.ID)
+				return
+			}
+			completion.flusher.Flush()
+		case <-r.Context().Done():
+			s.manager.Cancel(info.ID)
+			return
+		}
+	}
+
+	runID := info.ID
+	info, err = s.manager.Wait(r.Context(), runID)
+	if err != nil {
+		s.manager.Cancel(runID)
+		return
+	}
+
+	if info.Status != StatusSucceeded {
+		message := fmt.Sprintf("agent run %s %s", info.ID, info.Status)
+		if info.Error != "" {
+			message += ": " + info.Error
+		}
+		if req.Stream {
+			completion.writeData(map[string]interface{}{"error": openAIError{Message: message, Type: "server_error"}})
+			return
+		}
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "", message)
+		return
+	}
+
+	finishReason := openai.FinishReasonStop
+	if info.Incomplete {
+		finishReason = openai.FinishReasonLength
+	}
+
+	if req.Stream {
+		completion.writeChunk(openai.ChatCompletionStreamChoiceDelta{Content: info.Result}, "")
+		completion.writeChunk(openai.ChatCompletionStreamChoiceDelta{}, finishReason)
+		if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+			completion.writeData(openai.ChatCompletionStreamResponse{
+				ID:      completion.id,
+				Object:  "chat.completion.chunk",
+				Created: completion.created,
+				Model:   completion.model,
+				Choices: []openai.ChatCompletionStreamChoice{},
+				Usage:   &completion.usage,
+			})
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+		completion.flusher.Flush()
+		return
+	}
+
+	writeJSON(w, http.StatusOK, openai.ChatCompletionResponse{
+		ID:      completion.id,
+		Object:  "chat.completion",
+		Created: completion.created,
+		Model:   completion.model,
+		Choices: []openai.ChatCompletionChoice{{
+			Index: 0,
+			Message: openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: info.Result,
+			},
+			FinishReason: finishReason,
+		}},
+		Usage: completion.usage,
+	})
+}
+
+type chatCompletion struct {
+	id      string
+	created int64
+	model   string
+	usage   openai.Usage
+	seq     int
+
+	stream  http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (c *chatCompletion) observe(event Event) {
+	c.seq = event.Seq
+	if event.Usage != nil {
+		c.usage.PromptTokens += event.Usage.PromptTokens
+		c.usage.CompletionTokens += event.Usage.CompletionTokens
+		c.usage.TotalTokens += event.Usage.TotalTokens
+	}
+
+	if c.stream == nil || event.Type != "llm_output" || event.ParentRunID != "" || event.Thought == "" {
+		return
+	}
+	c.writeChunk(openai.ChatCompletionStreamChoiceDelta{ReasoningContent: event.Thought + "\n"}, "")
+}
+
+func (c *chatCompletion) writeChunk(delta openai.ChatCompletionStreamChoiceDelta, finishReason openai.FinishReason) {
+	c.writeData(openai.ChatCompletionStreamResponse{
+		ID:      c.id,
+		Object:  "chat.completion.chunk",
+		Created: c.created,
+		Model:   c.model,
+		Choices: []openai.ChatCompletionStreamChoice{{
+			Index:        0,
+			Delta:        delta,
+			FinishReason: finishReason,
+		}},
+	})
+}
+
+func (c *chatCompletion) writeData(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(c.stream, "data: %s\n\n", data)
+	c.flusher.Flush()
+}
+
+func chatInput(messages []openai.ChatCompletionMessage) string {
+	var turns []string
+	var last string
+	for _, m := range messages {
+		content := messageText(m)
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		turns = append(turns, fmt.Sprintf("%s: %s", m.Role, content))
+		last = content
+	}
+
+	if len(turns) <= 1 {
+		return last
+	}
+	return strings.Join(turns, "\n\n")
+}
+
+func messageText(m openai.ChatCompletionMessage) string {
+	if len(m.MultiContent) == 0 {
+		return m.Content
+	}
+	var parts []string
+	for _, part := range m.MultiContent {
+		if part.Type == openai.ChatMessagePartTypeText {
+			parts = append(parts, part.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func (s *Server) writeOpenAIManagerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownAgent):
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "model_not_found", err.Error())
+	case errors.Is(err, ErrQueueFull):
+		writeOpenAIError(w, http.StatusTooManyRequests, "rate_limit_exceeded", "", err.Error())
+	case errors.Is(err, ErrShuttingDown):
+		writeOpenAIError(w, http.StatusServiceUnavailable, "server_error", "", err.Error())
+	default:
+		s.logger.Error("chat completion failed", "error", err)
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "", err.Error())
+	}
+}
+
+func writeOpenAIError(w http.ResponseWriter, status int, errType, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": openAIError{Message: message, Type: errType, Code: code},
+	})
+}
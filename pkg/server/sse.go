@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const sseHeartbeat = 15 * time.Second
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	after := 0
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("after")
+	}
+	if lastID != "" {
+		n, err := strconv.Atoi(lastID)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid event id: "+lastID)
+			return
+		}
+		after = n
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	replay, events, unsubscribe, err := s.manager.Events(id, after)
+	if err != nil {
+		s.writeManagerError(w, err)
+		return
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range replay {
+		if err := writeSSE(w, strconv.Itoa(event.Seq), event.Type, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for events != nil {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if event.Type == eventLagged {
+				writeSSE(w, "", eventLagged, map[string]string{"error": "subscriber fell behind; reconnect with Last-Event-ID to resume"})
+				flusher.Flush()
+				return
+			}
+			if err := writeSSE(w, strconv.Itoa(event.Seq), event.Type, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	info, err := s.manager.Get(id)
+	if err != nil {
+		return
+	}
+	if !info.Status.Finished() {
+		return
+	}
+	writeSSE(w, "", "done", info)
+	flusher.Flush()
+}
+
+func writeSSE(w http.ResponseWriter, id, event string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
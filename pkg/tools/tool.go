@@ -17,6 +17,9 @@ type Tool interface {
 	Execute(ctx context.Context, args map[string]interface{}) (string, error)
 }
 
+// Deprecated: the agent renders tools through the "tools_section" template of
+// its prompt pack (see package prompts); this helper only matches the builtin
+// zh/en layout and will not reflect overridden templates.
 func FormatForPrompt(t Tool) (string, error) {
 	params := t.Parameters()
 
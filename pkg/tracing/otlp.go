@@ -0,0 +1,275 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const scopeName = "hivemind-go"
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+func EncodeOTLP(serviceName string, spans []SpanData) ([]byte, error) {
+	converted := make([]otlpSpan, len(spans))
+	for i, s := range spans {
+		converted[i] = otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentSpanID,
+			Name:              s.Name,
+			Kind:              int(s.Kind),
+			StartTimeUnixNano: unixNano(s.Start),
+			EndTimeUnixNano:   unixNano(s.End),
+			Attributes:        convertAttrs(s.Attrs),
+			Status:            otlpStatus{Code: int(s.StatusCode), Message: s.StatusMessage},
+		}
+		for _, e := range s.Events {
+			converted[i].Events = append(converted[i].Events, otlpEvent{
+				TimeUnixNano: unixNano(e.Time),
+				Name:         e.Name,
+				Attributes:   convertAttrs(e.Attrs),
+			})
+		}
+	}
+
+	req := otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: convertAttrs([]Attr{String("service.name", serviceName)}),
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: scopeName},
+				Spans: converted,
+			}},
+		}},
+	}
+	return json.Marshal(req)
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func convertAttrs(attrs []Attr) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		var v otlpAnyValue
+		switch val := a.Value.(type) {
+		case string:
+			v.StringValue = &val
+		case int64:
+			s := strconv.FormatInt(val, 10)
+			v.IntValue = &s
+		case int:
+			s := strconv.Itoa(val)
+			v.IntValue = &s
+		case float64:
+			v.DoubleValue = &val
+		case bool:
+			v.BoolValue = &val
+		default:
+			s := fmt.Sprint(val)
+			v.StringValue = &s
+		}
+		kvs = append(kvs, otlpKeyValue{Key: a.Key, Value: v})
+	}
+	return kvs
+}
+
+type FileExporter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileExporter(path string) (*FileExporter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create trace directory %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file %s: %w", path, err)
+	}
+	return &FileExporter{file: f}, nil
+}
+
+func (e *FileExporter) Export(_ context.Context, serviceName string, spans []SpanData) error {
+	payload, err := EncodeOTLP(serviceName, spans)
+	if err != nil {
+		return err
+	}
+	payload = append(payload, '\n')
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file == nil {
+		return os.ErrClosed
+	}
+	_, err = e.file.Write(payload)
+	return err
+}
+
+func (e *FileExporter) Shutdown(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file == nil {
+		return nil
+	}
+	err := e.file.Close()
+	e.file = nil
+	return err
+}
+
+type HTTPExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+func NewHTTPExporter(endpoint string) *HTTPExporter {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	return &HTTPExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (e *HTTPExporter) Export(ctx context.Context, serviceName string, spans []SpanData) error {
+	payload, err := EncodeOTLP(serviceName, spans)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans to %s: %w", e.endpoint, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("trace collector %s returned status %d", e.endpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+func (e *HTTPExporter) Shutdown(context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+type Config struct {
+	Exporter string `mapstructure:"exporter"`
+
+	Path string `mapstructure:"path"`
+
+	Endpoint string `mapstructure:"endpoint"`
+
+	ServiceName string `mapstructure:"service_name"`
+}
+
+func (c *Config) NewTracer() (*Tracer, error) {
+	serviceName := c.ServiceName
+	if serviceName == "" {
+		serviceName = "hivemind"
+	}
+
+	switch c.Exporter {
+	case "", "none":
+		return nil, nil
+	case "file":
+		path := c.Path
+		if path == "" {
+			path = filepath.Join("output", "traces.jsonl")
+		}
+		exporter, err := NewFileExporter(path)
+		if err != nil {
+			return nil, err
+		}
+		return NewTracer(serviceName, exporter), nil
+	case "otlp", "otlphttp":
+		endpoint := c.Endpoint
+		if endpoint == "" {
+			endpoint = "http://localhost:4318"
+		}
+		return NewTracer(serviceName, NewHTTPExporter(endpoint)), nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter '%s'", c.Exporter)
+	}
+}
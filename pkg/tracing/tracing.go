@@ -0,0 +1,308 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+type Attr struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key, value string) Attr { return Attr{Key: key, Value: value} }
+
+func Int(key string, value int) Attr { return Attr{Key: key, Value: int64(value)} }
+
+func Int64(key string, value int64) Attr { return Attr{Key: key, Value: value} }
+
+func Bool(key string, value bool) Attr { return Attr{Key: key, Value: value} }
+
+func Float64(key string, value float64) Attr { return Attr{Key: key, Value: value} }
+
+type Event struct {
+	Name  string
+	Time  time.Time
+	Attrs []Attr
+}
+
+type SpanData struct {
+	TraceID       string
+	SpanID        string
+	ParentSpanID  string
+	Name          string
+	Kind          SpanKind
+	Start         time.Time
+	End           time.Time
+	Attrs         []Attr
+	Events        []Event
+	StatusCode    StatusCode
+	StatusMessage string
+}
+
+type Exporter interface {
+	Export(ctx context.Context, serviceName string, spans []SpanData) error
+
+	Shutdown(ctx context.Context) error
+}
+
+const (
+	queueSize     = 2048
+	maxBatchSize  = 512
+	batchInterval = 2 * time.Second
+)
+
+type Tracer struct {
+	serviceName string
+	exporter    Exporter
+	logger      *slog.Logger
+
+	queue    chan SpanData
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	dropped  atomic.Int64
+}
+
+func NewTracer(serviceName string, exporter Exporter) *Tracer {
+	t := &Tracer{
+		serviceName: serviceName,
+		exporter:    exporter,
+		logger:      slog.Default(),
+		queue:       make(chan SpanData, queueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if exporter == nil {
+		close(t.done)
+		return t
+	}
+	go t.run()
+	return t
+}
+
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil || t.exporter == nil {
+		return nil
+	}
+	t.stopOnce.Do(func() { close(t.stop) })
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.exporter.Shutdown(ctx)
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			SpanID: newID(8),
+			Name:   name,
+			Kind:   kind,
+			Start:  time.Now(),
+			Attrs:  attrs,
+		},
+	}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentSpanID = parent.data.SpanID
+	} else {
+		span.data.TraceID = newID(16)
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (t *Tracer) export(data SpanData) {
+	if t.exporter == nil {
+		return
+	}
+	select {
+	case <-t.stop:
+		t.dropped.Add(1)
+		return
+	default:
+	}
+	select {
+	case t.queue <- data:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(batchInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, maxBatchSize)
+	flush := func() {
+		if dropped := t.dropped.Swap(0); dropped > 0 {
+			t.logger.Warn("trace queue full, spans dropped", "dropped", dropped)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.exporter.Export(context.Background(), t.serviceName, batch); err != nil {
+			t.logger.Warn("failed to export spans", "spans", len(batch), "error", err)
+		}
+		batch = make([]SpanData, 0, maxBatchSize)
+	}
+
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case data := <-t.queue:
+					batch = append(batch, data)
+					if len(batch) >= maxBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+type Span struct {
+	mu sync.Mutex
+
+	tracer *Tracer
+	data   SpanData
+	ended  bool
+}
+
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.data.TraceID
+}
+
+func (s *Span) SpanID() string {
+	if s == nil {
+		return ""
+	}
+	return s.data.SpanID
+}
+
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attrs = append(s.data.Attrs, attrs...)
+}
+
+func (s *Span) AddEvent(name string, attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Events = append(s.data.Events, Event{Name: name, Time: time.Now(), Attrs: attrs})
+}
+
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Events = append(s.data.Events, Event{
+		Name:  "exception",
+		Time:  time.Now(),
+		Attrs: []Attr{String("exception.message", err.Error())},
+	})
+	s.data.StatusCode = StatusError
+	s.data.StatusMessage = err.Error()
+}
+
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.export(data)
+}
+
+type spanKey struct{}
+
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+func Default() *Tracer {
+	return defaultTracer.Load()
+}
+
+func Start(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	if parent := SpanFromContext(ctx); parent != nil {
+		return parent.tracer.Start(ctx, name, kind, attrs...)
+	}
+	return Default().Start(ctx, name, kind, attrs...)
+}
+
+func newID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,131 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const FileName = "transcript.jsonl"
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type Record struct {
+	Time        time.Time `json:"time"`
+	RunID       string    `json:"run_id"`
+	ParentRunID string    `json:"parent_run_id,omitempty"`
+	Agent       string    `json:"agent"`
+	Iteration   int       `json:"iteration"`
+
+	Role    string `json:"role,omitempty"`
+	Type    string `json:"type"`
+	Content string `json:"content,omitempty"`
+
+	Thought string `json:"thought,omitempty"`
+	Action  string `json:"action,omitempty"`
+	Status  string `json:"status,omitempty"`
+
+	Tool       string                 `json:"tool,omitempty"`
+	ToolArgs   map[string]interface{} `json:"tool_args,omitempty"`
+	ToolResult string                 `json:"tool_result,omitempty"`
+	JobID      string                 `json:"job_id,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+
+	LatencyMS int64  `json:"latency_ms,omitempty"`
+	Usage     *Usage `json:"usage,omitempty"`
+}
+
+type Writer interface {
+	Write(rec Record) error
+}
+
+type JSONLWriter struct {
+	mu sync.Mutex
+
+	w io.Writer
+
+	closer io.Closer
+}
+
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{w: w}
+}
+
+func OpenFile(path string) (*JSONLWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript file %s: %w", path, err)
+	}
+	return &JSONLWriter{w: f, closer: f}, nil
+}
+
+func (j *JSONLWriter) Write(rec Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode transcript record: %w", err)
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.w == nil {
+		return os.ErrClosed
+	}
+	_, err = j.w.Write(line)
+	return err
+}
+
+func (j *JSONLWriter) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.w = nil
+	if j.closer == nil {
+		return nil
+	}
+	err := j.closer.Close()
+	j.closer = nil
+	return err
+}
+
+func Read(r io.Reader) ([]Record, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("invalid transcript record on line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return records, nil
+}
+
+func ReadFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript file %s: %w", path, err)
+	}
+	defer f.Close()
+	return Read(f)
+}